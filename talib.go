@@ -6,7 +6,12 @@ Licensed under terms of MIT license (see LICENSE)
 // Package talib is a pure Go port of TA-Lib (http://ta-lib.org) Technical Analysis Library
 package talib
 
-import "math"
+import (
+	"math"
+	"math/cmplx"
+	"sort"
+	"time"
+)
 
 // MaType - Moving average type
 type MaType int
@@ -27,16 +32,150 @@ const (
 	KAMA
 	MAMA
 	T3MA
+	HMA
+	ZLEMA
+	ALMA
+	SMMA
+	// VWMA needs a volume series: use VwmaSpec with MaWithSpec or a WithSpec function,
+	// as a bare VWMA carries no volume and yields all zeros
+	VWMA
+	LSMA
+	MCGINLEY
+	KALMAN
 )
 
+// RMA - Wilder's running moving average, the same as SMMA
+const RMA = SMMA
+
+// MaSpec - Moving average type together with its type specific parameters
+// A parameter is only used when its Has flag is set, otherwise the defaults Ma uses for the plain MaType apply
+// (MAMA: fastlimit=0.5, slowlimit=0.05; T3: vfactor=0.7; ALMA: offset=0.85, sigma=6).
+// VWMA weights inReal[i] by Volume[i] and yields zeros without a Volume covering inReal, so the plain VWMA MaType
+// (which carries no volume) is only usable through a VwmaSpec.
+// KALMAN without noise parameters derives them from the period so its steady state gain is the EMA alpha 2/(period+1).
+type MaSpec struct {
+	Type             MaType
+	FastLimit        float64
+	SlowLimit        float64
+	VFactor          float64
+	Offset           float64
+	Sigma            float64
+	Volume           []float64 // required by VWMA: must be at least as long as inReal, nil gives zeroed output
+	ProcessNoise     float64
+	ObservationNoise float64
+	HasLimits        bool // FastLimit and SlowLimit are set
+	HasVFactor       bool // VFactor is set
+	HasShape         bool // Offset and Sigma are set
+	HasNoise         bool // ProcessNoise and ObservationNoise are set
+}
+
+// Spec returns the moving average specification with default parameters
+func (t MaType) Spec() MaSpec {
+	return MaSpec{Type: t}
+}
+
+// MamaSpec - MAMA moving average specification with custom fast and slow limits
+func MamaSpec(inFastLimit float64, inSlowLimit float64) MaSpec {
+	return MaSpec{Type: MAMA, FastLimit: inFastLimit, SlowLimit: inSlowLimit, HasLimits: true}
+}
+
+// T3Spec - T3 moving average specification with a custom volume factor
+func T3Spec(inVFactor float64) MaSpec {
+	return MaSpec{Type: T3MA, VFactor: inVFactor, HasVFactor: true}
+}
+
+// AlmaSpec - ALMA moving average specification with a custom offset and sigma
+func AlmaSpec(inOffset float64, inSigma float64) MaSpec {
+	return MaSpec{Type: ALMA, Offset: inOffset, Sigma: inSigma, HasShape: true}
+}
+
+// VwmaSpec - VWMA moving average specification weighting the averaged series by inVolume
+func VwmaSpec(inVolume []float64) MaSpec {
+	return MaSpec{Type: VWMA, Volume: inVolume}
+}
+
+// KalmanSpec - Kalman local level moving average specification with custom process and observation noise
+func KalmanSpec(inProcessNoise float64, inObservationNoise float64) MaSpec {
+	return MaSpec{Type: KALMAN, ProcessNoise: inProcessNoise, ObservationNoise: inObservationNoise, HasNoise: true}
+}
+
 /* Overlap Studies */
 
+// AccBands - Acceleration Bands
+// upperband, middleband, lowerband = AccBands(high, low, close, timeperiod=20)
+func AccBands(inHigh []float64, inLow []float64, inClose []float64, inTimePeriod int) ([]float64, []float64, []float64) {
+
+	outRealUpperBand := make([]float64, len(inClose))
+	outRealMiddleBand := make([]float64, len(inClose))
+	outRealLowerBand := make([]float64, len(inClose))
+
+	if inTimePeriod < 2 || inTimePeriod > len(inClose) {
+		return outRealUpperBand, outRealMiddleBand, outRealLowerBand
+	}
+
+	tempBuffer1 := make([]float64, len(inClose))
+	tempBuffer2 := make([]float64, len(inClose))
+	for i := 0; i < len(inClose); i++ {
+		tempReal := inHigh[i] + inLow[i]
+		if !((-0.00000000000001 < tempReal) && (tempReal < 0.00000000000001)) {
+			tempReal = 4.0 * (inHigh[i] - inLow[i]) / tempReal
+			tempBuffer1[i] = inHigh[i] * (1.0 + tempReal)
+			tempBuffer2[i] = inLow[i] * (1.0 - tempReal)
+		} else {
+			tempBuffer1[i] = inHigh[i]
+			tempBuffer2[i] = inLow[i]
+		}
+	}
+
+	outRealMiddleBand = Sma(inClose, inTimePeriod)
+	outRealUpperBand = Sma(tempBuffer1, inTimePeriod)
+	outRealLowerBand = Sma(tempBuffer2, inTimePeriod)
+
+	return outRealUpperBand, outRealMiddleBand, outRealLowerBand
+}
+
+// Alma - Arnaud Legoux Moving Average
+// real = Alma(close, timeperiod=9, offset=0.85, sigma=6)
+func Alma(inReal []float64, inTimePeriod int, inOffset float64, inSigma float64) []float64 {
+
+	outReal := make([]float64, len(inReal))
+
+	if inTimePeriod < 1 {
+		return outReal
+	}
+
+	m := inOffset * float64(inTimePeriod-1)
+	s := float64(inTimePeriod) / inSigma
+	weights := make([]float64, inTimePeriod)
+	norm := 0.0
+	for i := 0; i < inTimePeriod; i++ {
+		tempReal := float64(i) - m
+		weights[i] = math.Exp(-(tempReal * tempReal) / (2.0 * s * s))
+		norm += weights[i]
+	}
+
+	for today := inTimePeriod - 1; today < len(inReal); today++ {
+		sum := 0.0
+		trailingIdx := today - inTimePeriod + 1
+		for i := 0; i < inTimePeriod; i++ {
+			sum += weights[i] * inReal[trailingIdx+i]
+		}
+		outReal[today] = sum / norm
+	}
+	return outReal
+}
+
 // BBands - Bollinger Bands
 // upperband, middleband, lowerband = BBands(close, timeperiod=5, nbdevup=2, nbdevdn=2, matype=0)
 func BBands(inReal []float64, inTimePeriod int, inNbDevUp float64, inNbDevDn float64, inMAType MaType) ([]float64, []float64, []float64) {
+	return BBandsWithSpec(inReal, inTimePeriod, inNbDevUp, inNbDevDn, inMAType.Spec())
+}
+
+// BBandsWithSpec - BBands taking MaSpec moving averages with custom type specific parameters
+func BBandsWithSpec(inReal []float64, inTimePeriod int, inNbDevUp float64, inNbDevDn float64, inMASpec MaSpec) ([]float64, []float64, []float64) {
 
 	outRealUpperBand := make([]float64, len(inReal))
-	outRealMiddleBand := Ma(inReal, inTimePeriod, inMAType)
+	outRealMiddleBand := MaWithSpec(inReal, inTimePeriod, inMASpec)
 	outRealLowerBand := make([]float64, len(inReal))
 
 	tempBuffer2 := StdDev(inReal, inTimePeriod, 1.0)
@@ -83,6 +222,40 @@ func BBands(inReal []float64, inTimePeriod int, inNbDevUp float64, inNbDevDn flo
 	return outRealUpperBand, outRealMiddleBand, outRealLowerBand
 }
 
+// BandPercentB - position of the price within any band: (price-lower)/(upper-lower)
+// e.g. BandPercentB(close, upper, lower) with the outputs of BBands, Keltner, Donchian, Starc or MaEnvelope
+func BandPercentB(inReal []float64, inUpperBand []float64, inLowerBand []float64) []float64 {
+
+	outReal := make([]float64, len(inReal))
+
+	for i := 0; i < len(inReal); i++ {
+		tempReal := inUpperBand[i] - inLowerBand[i]
+		if !((-0.00000000000001 < tempReal) && (tempReal < 0.00000000000001)) {
+			outReal[i] = (inReal[i] - inLowerBand[i]) / tempReal
+		} else {
+			outReal[i] = 0.0
+		}
+	}
+	return outReal
+}
+
+// BandWidth - relative width of any band: (upper-lower)/middle
+// e.g. BandWidth(BBands(close, 20, 2, 2, SMA)) for the Bollinger squeeze
+func BandWidth(inUpperBand []float64, inMiddleBand []float64, inLowerBand []float64) []float64 {
+
+	outReal := make([]float64, len(inMiddleBand))
+
+	for i := 0; i < len(inMiddleBand); i++ {
+		tempReal := inMiddleBand[i]
+		if !((-0.00000000000001 < tempReal) && (tempReal < 0.00000000000001)) {
+			outReal[i] = (inUpperBand[i] - inLowerBand[i]) / tempReal
+		} else {
+			outReal[i] = 0.0
+		}
+	}
+	return outReal
+}
+
 // Dema - Double Exponential Moving Average
 func Dema(inReal []float64, inTimePeriod int) []float64 {
 
@@ -97,6 +270,37 @@ func Dema(inReal []float64, inTimePeriod int) []float64 {
 	return outReal
 }
 
+// Decycler - Ehlers Simple Decycler: the input minus its cycles shorter than timeperiod bars
+// real = Decycler(close, timeperiod=60)
+func Decycler(inReal []float64, inTimePeriod int) []float64 {
+
+	if inTimePeriod < 1 {
+		return make([]float64, len(inReal))
+	}
+	tempReal := 2.0 * math.Pi / float64(inTimePeriod)
+	alpha := (math.Cos(tempReal) + math.Sin(tempReal) - 1.0) / math.Cos(tempReal)
+	return iirFilter(inReal, []float64{alpha / 2.0, alpha / 2.0}, []float64{1.0, alpha - 1.0}, inReal, 1)
+}
+
+// Donchian - Donchian Channels
+// upperband, middleband, lowerband = Donchian(high, low, timeperiod=20)
+func Donchian(inHigh []float64, inLow []float64, inTimePeriod int) ([]float64, []float64, []float64) {
+
+	outRealMiddleBand := make([]float64, len(inHigh))
+
+	if inTimePeriod < 1 {
+		return make([]float64, len(inHigh)), outRealMiddleBand, make([]float64, len(inHigh))
+	}
+
+	_, outRealUpperBand := MinMax(inHigh, inTimePeriod)
+	outRealLowerBand, _ := MinMax(inLow, inTimePeriod)
+	for i := inTimePeriod - 1; i < len(inHigh); i++ {
+		outRealMiddleBand[i] = (outRealUpperBand[i] + outRealLowerBand[i]) / 2.0
+	}
+
+	return outRealUpperBand, outRealMiddleBand, outRealLowerBand
+}
+
 // Ema - Exponential Moving Average
 func ema(inReal []float64, inTimePeriod int, k1 float64) []float64 {
 
@@ -139,6 +343,35 @@ func Ema(inReal []float64, inTimePeriod int) []float64 {
 	return outReal
 }
 
+// Hma - Hull Moving Average: Wma(2*Wma(n/2) - Wma(n), sqrt(n))
+func Hma(inReal []float64, inTimePeriod int) []float64 {
+
+	outReal := make([]float64, len(inReal))
+
+	halfPeriod := inTimePeriod / 2
+	sqrtPeriod := int(math.Sqrt(float64(inTimePeriod)))
+	if halfPeriod < 1 || sqrtPeriod < 1 {
+		copy(outReal, inReal)
+		return outReal
+	}
+	lookbackTotal := (inTimePeriod - 1) + (sqrtPeriod - 1)
+	if lookbackTotal >= len(inReal) {
+		return outReal
+	}
+
+	halfWMA := Wma(inReal, halfPeriod)
+	fullWMA := Wma(inReal, inTimePeriod)
+	tempBuffer := make([]float64, len(inReal)-(inTimePeriod-1))
+	for i, today := 0, inTimePeriod-1; today < len(inReal); i, today = i+1, today+1 {
+		tempBuffer[i] = (2.0 * halfWMA[today]) - fullWMA[today]
+	}
+	hullWMA := Wma(tempBuffer, sqrtPeriod)
+	for i, today := sqrtPeriod-1, lookbackTotal; today < len(inReal); i, today = i+1, today+1 {
+		outReal[today] = hullWMA[i]
+	}
+	return outReal
+}
+
 // HtTrendline - Hilbert Transform - Instantaneous Trendline (lookback=63)
 func HtTrendline(inReal []float64) []float64 {
 
@@ -376,10 +609,305 @@ func HtTrendline(inReal []float64) []float64 {
 	return outReal
 }
 
+// Ichimoku - Ichimoku Kinko Hyo
+// tenkan, kijun, senkoua, senkoub, chikou = Ichimoku(high, low, close, tenkanperiod=9, kijunperiod=26, senkoubperiod=52, displacement=26)
+//
+// Senkou spans are shifted forward by displacement bars and have len(inClose)+displacement elements,
+// the last displacement values being the cloud ahead of the last bar.
+// Chikou is the close shifted back by displacement bars, its last displacement values are 0.
+// Periods below 1 or a negative displacement yield zeroed outputs of len(inClose).
+func Ichimoku(inHigh []float64, inLow []float64, inClose []float64, inTenkanPeriod int, inKijunPeriod int, inSenkouBPeriod int, inDisplacement int) ([]float64, []float64, []float64, []float64, []float64) {
+
+	if inTenkanPeriod < 1 || inKijunPeriod < 1 || inSenkouBPeriod < 1 || inDisplacement < 0 {
+		return make([]float64, len(inClose)), make([]float64, len(inClose)), make([]float64, len(inClose)), make([]float64, len(inClose)), make([]float64, len(inClose))
+	}
+
+	outSenkouA := make([]float64, len(inClose)+inDisplacement)
+	outSenkouB := make([]float64, len(inClose)+inDisplacement)
+	outChikou := make([]float64, len(inClose))
+
+	outTenkan := MidPrice(inHigh, inLow, inTenkanPeriod)
+	outKijun := MidPrice(inHigh, inLow, inKijunPeriod)
+	senkouB := MidPrice(inHigh, inLow, inSenkouBPeriod)
+
+	startIdx := inTenkanPeriod - 1
+	if inKijunPeriod > inTenkanPeriod {
+		startIdx = inKijunPeriod - 1
+	}
+	for today := startIdx; today < len(inClose); today++ {
+		outSenkouA[today+inDisplacement] = (outTenkan[today] + outKijun[today]) / 2.0
+	}
+	for today := inSenkouBPeriod - 1; today < len(inClose); today++ {
+		outSenkouB[today+inDisplacement] = senkouB[today]
+	}
+	for today := inDisplacement; today < len(inClose); today++ {
+		outChikou[today-inDisplacement] = inClose[today]
+	}
+
+	return outTenkan, outKijun, outSenkouA, outSenkouB, outChikou
+}
+
+// IchimokuValue - Ichimoku lines produced by IchimokuStream for one bar
+type IchimokuValue struct {
+	Tenkan float64
+	Kijun  float64
+	// SenkouA and SenkouB are the cloud at the current bar, computed displacement bars ago
+	SenkouA float64
+	SenkouB float64
+	// LeadingA and LeadingB are computed from the current bar and belong displacement bars ahead
+	LeadingA float64
+	LeadingB float64
+	// Chikou is the current close, which belongs displacement bars back
+	Chikou float64
+}
+
+// IchimokuStream - incremental Ichimoku Kinko Hyo, fed one bar at a time.
+// Values match Ichimoku on the same bars, lines still inside their lookback are 0.
+type IchimokuStream struct {
+	tenkanPeriod  int
+	kijunPeriod   int
+	senkouBPeriod int
+	displacement  int
+	highs         []float64
+	lows          []float64
+	leadingA      []float64
+	leadingB      []float64
+	count         int
+}
+
+// NewIchimokuStream - creates an IchimokuStream, see Ichimoku for the parameters
+func NewIchimokuStream(inTenkanPeriod int, inKijunPeriod int, inSenkouBPeriod int, inDisplacement int) *IchimokuStream {
+	if inTenkanPeriod < 1 || inKijunPeriod < 1 || inSenkouBPeriod < 1 || inDisplacement < 0 {
+		return &IchimokuStream{}
+	}
+	maxPeriod := inTenkanPeriod
+	if inKijunPeriod > maxPeriod {
+		maxPeriod = inKijunPeriod
+	}
+	if inSenkouBPeriod > maxPeriod {
+		maxPeriod = inSenkouBPeriod
+	}
+	return &IchimokuStream{
+		tenkanPeriod:  inTenkanPeriod,
+		kijunPeriod:   inKijunPeriod,
+		senkouBPeriod: inSenkouBPeriod,
+		displacement:  inDisplacement,
+		highs:         make([]float64, maxPeriod),
+		lows:          make([]float64, maxPeriod),
+		leadingA:      make([]float64, inDisplacement+1),
+		leadingB:      make([]float64, inDisplacement+1),
+	}
+}
+
+// Update adds a bar and returns the Ichimoku lines for it
+func (s *IchimokuStream) Update(inHigh float64, inLow float64, inClose float64) IchimokuValue {
+
+	if len(s.highs) == 0 {
+		return IchimokuValue{}
+	}
+	size := len(s.highs)
+	s.highs[s.count%size] = inHigh
+	s.lows[s.count%size] = inLow
+	s.count++
+
+	value := IchimokuValue{Chikou: inClose}
+	value.Tenkan = s.midPrice(s.tenkanPeriod)
+	value.Kijun = s.midPrice(s.kijunPeriod)
+	if s.count >= s.tenkanPeriod && s.count >= s.kijunPeriod {
+		value.LeadingA = (value.Tenkan + value.Kijun) / 2.0
+	}
+	value.LeadingB = s.midPrice(s.senkouBPeriod)
+
+	slot := (s.count - 1) % len(s.leadingA)
+	s.leadingA[slot] = value.LeadingA
+	s.leadingB[slot] = value.LeadingB
+	if s.count > s.displacement {
+		pastSlot := (s.count - 1 - s.displacement) % len(s.leadingA)
+		value.SenkouA = s.leadingA[pastSlot]
+		value.SenkouB = s.leadingB[pastSlot]
+	}
+	return value
+}
+
+// midPrice - (highest high + lowest low)/2 over the last inTimePeriod bars, 0 until enough bars were seen
+func (s *IchimokuStream) midPrice(inTimePeriod int) float64 {
+
+	if inTimePeriod < 1 || s.count < inTimePeriod {
+		return 0.0
+	}
+	size := len(s.highs)
+	idx := (s.count - 1) % size
+	highest := s.highs[idx]
+	lowest := s.lows[idx]
+	for i := 1; i < inTimePeriod; i++ {
+		idx = (s.count - 1 - i) % size
+		if s.highs[idx] > highest {
+			highest = s.highs[idx]
+		}
+		if s.lows[idx] < lowest {
+			lowest = s.lows[idx]
+		}
+	}
+	return (highest + lowest) / 2.0
+}
+
+// InstantaneousTrendline - Ehlers 2 pole Instantaneous Trendline and its trigger line
+// itrend, trigger = InstantaneousTrendline(close, alpha=0.07)
+//
+// trigger = 2*itrend - itrend[2]; the first seven bars are a [1 2 1] average of the input.
+func InstantaneousTrendline(inReal []float64, inAlpha float64) ([]float64, []float64) {
+
+	seed := make([]float64, len(inReal))
+	for today := 0; today < 7 && today < len(inReal); today++ {
+		if today < 2 {
+			seed[today] = inReal[today]
+		} else {
+			seed[today] = (inReal[today] + (2.0 * inReal[today-1]) + inReal[today-2]) / 4.0
+		}
+	}
+	alpha2 := inAlpha * inAlpha
+	b := []float64{inAlpha - (alpha2 / 4.0), alpha2 / 2.0, -(inAlpha - (0.75 * alpha2))}
+	a := []float64{1.0, -2.0 * (1.0 - inAlpha), (1.0 - inAlpha) * (1.0 - inAlpha)}
+	outITrend := iirFilter(inReal, b, a, seed, 7)
+
+	outTrigger := make([]float64, len(inReal))
+	copy(outTrigger, outITrend)
+	for today := 2; today < len(inReal); today++ {
+		outTrigger[today] = (2.0 * outITrend[today]) - outITrend[today-2]
+	}
+	return outITrend, outTrigger
+}
+
+// Keltner - Keltner Channels: moving average of close +/- multiplier * Atr
+// upperband, middleband, lowerband = Keltner(high, low, close, timeperiod=20, atrperiod=10, multiplier=2, matype=EMA)
+func Keltner(inHigh []float64, inLow []float64, inClose []float64, inTimePeriod int, inAtrPeriod int, inMultiplier float64, inMAType MaType) ([]float64, []float64, []float64) {
+
+	if inTimePeriod < 1 {
+		return make([]float64, len(inClose)), make([]float64, len(inClose)), make([]float64, len(inClose))
+	}
+	return atrBands(inHigh, inLow, inClose, Ma(inClose, inTimePeriod, inMAType), maLookback(inTimePeriod, inMAType.Spec()), inAtrPeriod, inMultiplier)
+}
+
+// atrBands - fills bands at middle +/- multiplier * Atr from the first bar where both middle and Atr are set
+func atrBands(inHigh []float64, inLow []float64, inClose []float64, inMiddle []float64, inMiddleLookback int, inAtrPeriod int, inMultiplier float64) ([]float64, []float64, []float64) {
+
+	outRealUpperBand := make([]float64, len(inClose))
+	outRealMiddleBand := make([]float64, len(inClose))
+	outRealLowerBand := make([]float64, len(inClose))
+
+	if inAtrPeriod < 1 {
+		return outRealUpperBand, outRealMiddleBand, outRealLowerBand
+	}
+	atr := Atr(inHigh, inLow, inClose, inAtrPeriod)
+	startIdx := inAtrPeriod
+	if inMiddleLookback > startIdx {
+		startIdx = inMiddleLookback
+	}
+	for i := startIdx; i < len(inClose); i++ {
+		tempReal := atr[i] * inMultiplier
+		outRealMiddleBand[i] = inMiddle[i]
+		outRealUpperBand[i] = inMiddle[i] + tempReal
+		outRealLowerBand[i] = inMiddle[i] - tempReal
+	}
+	return outRealUpperBand, outRealMiddleBand, outRealLowerBand
+}
+
+// KalmanLevel - Kalman filter of a local level (random walk plus noise) model
+// level, variance, innovation = KalmanLevel(close, processnoise, observationnoise)
+//
+// level follows a random walk with variance processnoise per bar and is observed with variance observationnoise;
+// only their ratio shapes the level. variance is the filtered variance of the level and innovation the
+// surprise of each bar against the level predicted from the previous one. The filter starts at the first bar.
+func KalmanLevel(inReal []float64, inProcessNoise float64, inObservationNoise float64) ([]float64, []float64, []float64) {
+
+	outLevel := make([]float64, len(inReal))
+	outVariance := make([]float64, len(inReal))
+	outInnovation := make([]float64, len(inReal))
+
+	if len(inReal) == 0 {
+		return outLevel, outVariance, outInnovation
+	}
+	level := inReal[0]
+	variance := inObservationNoise
+	outLevel[0] = level
+	outVariance[0] = variance
+	for today := 1; today < len(inReal); today++ {
+		prior := variance + inProcessNoise
+		innovation := inReal[today] - level
+		gain := prior / (prior + inObservationNoise)
+		level += gain * innovation
+		variance = (1.0 - gain) * prior
+		outLevel[today] = level
+		outVariance[today] = variance
+		outInnovation[today] = innovation
+	}
+	return outLevel, outVariance, outInnovation
+}
+
+// KalmanTrend - Kalman filter of a local linear trend model
+// level, slope, variance, innovation = KalmanTrend(close, levelnoise, slopenoise, observationnoise)
+//
+// level grows by slope every bar, both drifting with the given process noise variances. variance is the filtered
+// variance of the level and innovation the surprise against the predicted level. The state is initialised
+// exactly from the first two bars.
+func KalmanTrend(inReal []float64, inLevelNoise float64, inSlopeNoise float64, inObservationNoise float64) ([]float64, []float64, []float64, []float64) {
+
+	outLevel := make([]float64, len(inReal))
+	outSlope := make([]float64, len(inReal))
+	outVariance := make([]float64, len(inReal))
+	outInnovation := make([]float64, len(inReal))
+
+	if len(inReal) == 0 {
+		return outLevel, outSlope, outVariance, outInnovation
+	}
+	outLevel[0] = inReal[0]
+	outVariance[0] = inObservationNoise
+	if len(inReal) == 1 {
+		return outLevel, outSlope, outVariance, outInnovation
+	}
+	level := inReal[1]
+	slope := inReal[1] - inReal[0]
+	p00, p01, p11 := inObservationNoise, inObservationNoise, 2.0*inObservationNoise
+	outLevel[1] = level
+	outSlope[1] = slope
+	outVariance[1] = p00
+	outInnovation[1] = slope
+	for today := 2; today < len(inReal); today++ {
+		level += slope
+		p00 += (2.0 * p01) + p11 + inLevelNoise
+		p01 += p11
+		p11 += inSlopeNoise
+		innovation := inReal[today] - level
+		tempReal := p00 + inObservationNoise
+		gain0 := p00 / tempReal
+		gain1 := p01 / tempReal
+		level += gain0 * innovation
+		slope += gain1 * innovation
+		p11 -= gain1 * p01
+		p00 *= 1.0 - gain0
+		p01 *= 1.0 - gain0
+		outLevel[today] = level
+		outSlope[today] = slope
+		outVariance[today] = p00
+		outInnovation[today] = innovation
+	}
+	return outLevel, outSlope, outVariance, outInnovation
+}
+
 // Kama - Kaufman Adaptive Moving Average
 func Kama(inReal []float64, inTimePeriod int) []float64 {
 
+	outReal, _, _ := KamaDetail(inReal, inTimePeriod)
+	return outReal
+}
+
+// KamaDetail - Kaufman Adaptive Moving Average with the efficiency ratio and the smoothing constant of every bar
+// kama, efficiencyratio, smoothingconstant = KamaDetail(close, timeperiod=30)
+func KamaDetail(inReal []float64, inTimePeriod int) ([]float64, []float64, []float64) {
+
 	outReal := make([]float64, len(inReal))
+	outER := make([]float64, len(inReal))
+	outSC := make([]float64, len(inReal))
 
 	constMax := 2.0 / (30.0 + 1.0)
 	constDiff := 2.0/(2.0+1.0) - constMax
@@ -402,13 +930,11 @@ func Kama(inReal []float64, inTimePeriod int) []float64 {
 	trailingIdx++
 	periodROC := tempReal - tempReal2
 	trailingValue := tempReal2
-	if (sumROC1 <= periodROC) || (((-(0.00000000000001)) < sumROC1) && (sumROC1 < (0.00000000000001))) {
-		tempReal = 1.0
-	} else {
-		tempReal = math.Abs(periodROC / sumROC1)
-	}
+	tempReal = kamaEfficiencyRatio(periodROC, sumROC1)
+	efficiencyRatio := tempReal
 	tempReal = (tempReal * constDiff) + constMax
 	tempReal *= tempReal
+	smoothingConstant := tempReal
 	prevKAMA = ((inReal[today] - prevKAMA) * tempReal) + prevKAMA
 	today++
 	for today <= startIdx {
@@ -419,17 +945,17 @@ func Kama(inReal []float64, inTimePeriod int) []float64 {
 		sumROC1 -= math.Abs(trailingValue - tempReal2)
 		sumROC1 += math.Abs(tempReal - inReal[today-1])
 		trailingValue = tempReal2
-		if (sumROC1 <= periodROC) || (((-(0.00000000000001)) < sumROC1) && (sumROC1 < (0.00000000000001))) {
-			tempReal = 1.0
-		} else {
-			tempReal = math.Abs(periodROC / sumROC1)
-		}
+		tempReal = kamaEfficiencyRatio(periodROC, sumROC1)
+		efficiencyRatio = tempReal
 		tempReal = (tempReal * constDiff) + constMax
 		tempReal *= tempReal
+		smoothingConstant = tempReal
 		prevKAMA = ((inReal[today] - prevKAMA) * tempReal) + prevKAMA
 		today++
 	}
 	outReal[inTimePeriod] = prevKAMA
+	outER[inTimePeriod] = efficiencyRatio
+	outSC[inTimePeriod] = smoothingConstant
 	outIdx := inTimePeriod + 1
 	for today < len(inReal) {
 		tempReal = inReal[today]
@@ -439,24 +965,65 @@ func Kama(inReal []float64, inTimePeriod int) []float64 {
 		sumROC1 -= math.Abs(trailingValue - tempReal2)
 		sumROC1 += math.Abs(tempReal - inReal[today-1])
 		trailingValue = tempReal2
-		if (sumROC1 <= periodROC) || (((-(0.00000000000001)) < sumROC1) && (sumROC1 < (0.00000000000001))) {
-			tempReal = 1.0
-		} else {
-			tempReal = math.Abs(periodROC / sumROC1)
-		}
+		tempReal = kamaEfficiencyRatio(periodROC, sumROC1)
+		efficiencyRatio = tempReal
 		tempReal = (tempReal * constDiff) + constMax
 		tempReal *= tempReal
+		smoothingConstant = tempReal
 		prevKAMA = ((inReal[today] - prevKAMA) * tempReal) + prevKAMA
 		today++
 		outReal[outIdx] = prevKAMA
+		outER[outIdx] = efficiencyRatio
+		outSC[outIdx] = smoothingConstant
 		outIdx++
 	}
 
+	return outReal, outER, outSC
+}
+
+// kamaEfficiencyRatio - Kaufman efficiency ratio of a net change and the sum of absolute changes over the same bars,
+// 1.0 when the sum does not exceed the net change, including the flat case where both are 0
+func kamaEfficiencyRatio(periodROC float64, sumROC1 float64) float64 {
+	if (sumROC1 <= periodROC) || (((-(0.00000000000001)) < sumROC1) && (sumROC1 < (0.00000000000001))) {
+		return 1.0
+	}
+	return math.Abs(periodROC / sumROC1)
+}
+
+// LaguerreFilter - Ehlers Laguerre filter, a four element Laguerre smoother with damping gamma
+// real = LaguerreFilter(close, gamma=0.8)
+func LaguerreFilter(inReal []float64, inGamma float64) []float64 {
+
+	l0, l1, l2, l3 := laguerre(inReal, inGamma)
+	outReal := make([]float64, len(inReal))
+	for today := range inReal {
+		outReal[today] = (l0[today] + (2.0 * l1[today]) + (2.0 * l2[today]) + l3[today]) / 6.0
+	}
 	return outReal
 }
 
+// laguerre - the four Laguerre elements: a one pole low pass followed by three all pass sections,
+// all starting at the first input value
+func laguerre(inReal []float64, inGamma float64) ([]float64, []float64, []float64, []float64) {
+
+	a := []float64{1.0, -inGamma}
+	allPass := []float64{-inGamma, 1.0}
+	l0 := iirFilter(inReal, []float64{1.0 - inGamma}, a, inReal, 1)
+	l1 := iirFilter(l0, allPass, a, inReal, 1)
+	l2 := iirFilter(l1, allPass, a, inReal, 1)
+	l3 := iirFilter(l2, allPass, a, inReal, 1)
+	return l0, l1, l2, l3
+}
+
 // Ma - Moving average
+// VWMA is not available here since a MaType carries no volume, use MaWithSpec(inReal, period, VwmaSpec(volume)).
 func Ma(inReal []float64, inTimePeriod int, inMAType MaType) []float64 {
+	return MaWithSpec(inReal, inTimePeriod, inMAType.Spec())
+}
+
+// MaWithSpec - Moving average selected by a MaSpec carrying custom type specific parameters
+// A VWMA spec must carry a Volume covering inReal (see VwmaSpec), otherwise the output is all zeros.
+func MaWithSpec(inReal []float64, inTimePeriod int, inMASpec MaSpec) []float64 {
 
 	outReal := make([]float64, len(inReal))
 
@@ -465,7 +1032,7 @@ func Ma(inReal []float64, inTimePeriod int, inMAType MaType) []float64 {
 		return outReal
 	}
 
-	switch inMAType {
+	switch inMASpec.Type {
 	case SMA:
 		outReal = Sma(inReal, inTimePeriod)
 	case EMA:
@@ -481,18 +1048,132 @@ func Ma(inReal []float64, inTimePeriod int, inMAType MaType) []float64 {
 	case KAMA:
 		outReal = Kama(inReal, inTimePeriod)
 	case MAMA:
-		outReal, _ = Mama(inReal, 0.5, 0.05)
+		fastLimit, slowLimit := 0.5, 0.05
+		if inMASpec.HasLimits {
+			fastLimit, slowLimit = inMASpec.FastLimit, inMASpec.SlowLimit
+		}
+		outReal, _ = Mama(inReal, fastLimit, slowLimit)
+	case T3MA:
+		vFactor := 0.7
+		if inMASpec.HasVFactor {
+			vFactor = inMASpec.VFactor
+		}
+		outReal = T3(inReal, inTimePeriod, vFactor)
+	case HMA:
+		outReal = Hma(inReal, inTimePeriod)
+	case ZLEMA:
+		outReal = Zlema(inReal, inTimePeriod)
+	case ALMA:
+		offset, sigma := 0.85, 6.0
+		if inMASpec.HasShape {
+			offset, sigma = inMASpec.Offset, inMASpec.Sigma
+		}
+		outReal = Alma(inReal, inTimePeriod, offset, sigma)
+	case SMMA:
+		outReal = Smma(inReal, inTimePeriod)
+	case VWMA:
+		// a VWMA spec without a Volume covering inReal is rejected with zeroed output
+		if len(inMASpec.Volume) >= len(inReal) {
+			outReal = Vwma(inReal, inMASpec.Volume, inTimePeriod)
+		}
+	case LSMA:
+		outReal = LinearReg(inReal, inTimePeriod)
+	case MCGINLEY:
+		outReal = McGinleyDynamic(inReal, inTimePeriod)
+	case KALMAN:
+		// local level model whose steady state gain k satisfies q/r = k^2/(1-k)
+		processNoise, observationNoise := inMASpec.ProcessNoise, inMASpec.ObservationNoise
+		if !inMASpec.HasNoise {
+			gain := 2.0 / float64(inTimePeriod+1)
+			processNoise, observationNoise = gain*gain/(1.0-gain), 1.0
+		}
+		outReal, _, _ = KalmanLevel(inReal, processNoise, observationNoise)
+	}
+	return outReal
+}
+
+// maLookback - number of leading bars Ma leaves unset for the given period and type
+func maLookback(inTimePeriod int, inMASpec MaSpec) int {
+
+	if inTimePeriod <= 1 {
+		return 0
+	}
+
+	switch inMASpec.Type {
+	case DEMA:
+		return 2 * (inTimePeriod - 1)
+	case TEMA:
+		return 3 * (inTimePeriod - 1)
+	case KAMA:
+		return inTimePeriod
+	case MAMA:
+		return 32
 	case T3MA:
-		outReal = T3(inReal, inTimePeriod, 0.7)
+		return 6 * (inTimePeriod - 1)
+	case HMA:
+		return (inTimePeriod - 1) + (int(math.Sqrt(float64(inTimePeriod))) - 1)
+	case ZLEMA:
+		return ((inTimePeriod - 1) / 2) + (inTimePeriod - 1)
+	case KALMAN:
+		return 0
+	}
+	return inTimePeriod - 1
+}
+
+// MaEnvelope - Moving average envelope at +/- percent of the moving average
+// upperband, middleband, lowerband = MaEnvelope(close, timeperiod=20, percent=2.5, matype=SMA)
+func MaEnvelope(inReal []float64, inTimePeriod int, inPercent float64, inMAType MaType) ([]float64, []float64, []float64) {
+
+	outRealUpperBand := make([]float64, len(inReal))
+	outRealLowerBand := make([]float64, len(inReal))
+
+	if inTimePeriod < 1 {
+		return outRealUpperBand, make([]float64, len(inReal)), outRealLowerBand
 	}
+	outRealMiddleBand := Ma(inReal, inTimePeriod, inMAType)
+
+	upFactor := 1.0 + (inPercent / 100.0)
+	downFactor := 1.0 - (inPercent / 100.0)
+	for i := maLookback(inTimePeriod, inMAType.Spec()); i < len(inReal); i++ {
+		outRealUpperBand[i] = outRealMiddleBand[i] * upFactor
+		outRealLowerBand[i] = outRealMiddleBand[i] * downFactor
+	}
+
+	return outRealUpperBand, outRealMiddleBand, outRealLowerBand
+}
+
+// maFrom - Ma of a series whose values start at startIdx, aligned with the input and 0 inside the combined lookback
+func maFrom(inReal []float64, startIdx int, inTimePeriod int, inMASpec MaSpec) []float64 {
+
+	outReal := make([]float64, len(inReal))
+
+	lookbackTotal := startIdx + maLookback(inTimePeriod, inMASpec)
+	if inTimePeriod < 1 || lookbackTotal >= len(inReal) {
+		return outReal
+	}
+	if len(inMASpec.Volume) > startIdx {
+		inMASpec.Volume = inMASpec.Volume[startIdx:]
+	}
+	tempBuffer := MaWithSpec(inReal[startIdx:], inTimePeriod, inMASpec)
+	copy(outReal[lookbackTotal:], tempBuffer[lookbackTotal-startIdx:])
 	return outReal
 }
 
 // Mama - MESA Adaptive Moving Average (lookback=32)
 func Mama(inReal []float64, inFastLimit float64, inSlowLimit float64) ([]float64, []float64) {
 
+	outMAMA, outFAMA, _, _ := MamaDetail(inReal, inFastLimit, inSlowLimit)
+	return outMAMA, outFAMA
+}
+
+// MamaDetail - MESA Adaptive Moving Average with the smoothed dominant cycle period and the phase (degrees) of every bar
+// mama, fama, period, phase = MamaDetail(close, fastlimit=0.5, slowlimit=0.05)
+func MamaDetail(inReal []float64, inFastLimit float64, inSlowLimit float64) ([]float64, []float64, []float64, []float64) {
+
 	outMAMA := make([]float64, len(inReal))
 	outFAMA := make([]float64, len(inReal))
+	outPeriod := make([]float64, len(inReal))
+	outPhase := make([]float64, len(inReal))
 
 	a := 0.0962
 	b := 0.5769
@@ -735,7 +1416,7 @@ func Mama(inReal []float64, inFastLimit float64, inSlowLimit float64) ([]float64
 		if today >= startIdx {
 			outMAMA[outIdx] = mama
 			outFAMA[outIdx] = fama
-			outIdx++
+			outPhase[outIdx] = prevPhase
 		}
 		Re = (0.2 * ((i2 * previ2) + (q2 * prevq2))) + (0.8 * Re)
 		Im = (0.2 * ((i2 * prevq2) - (q2 * previ2))) + (0.8 * Im)
@@ -759,16 +1440,26 @@ func Mama(inReal []float64, inFastLimit float64, inSlowLimit float64) ([]float64
 			period = 50
 		}
 		period = (0.2 * period) + (0.8 * tempReal)
+		if today >= startIdx {
+			outPeriod[outIdx] = period
+			outIdx++
+		}
 		today++
 	}
-	return outMAMA, outFAMA
+	return outMAMA, outFAMA, outPeriod, outPhase
 }
 
 // MaVp - Moving average with variable period
+// The lookback is the one of the moving average at inMaxPeriod, as in TA-Lib 0.6
 func MaVp(inReal []float64, inPeriods []float64, inMinPeriod int, inMaxPeriod int, inMAType MaType) []float64 {
+	return MaVpWithSpec(inReal, inPeriods, inMinPeriod, inMaxPeriod, inMAType.Spec())
+}
+
+// MaVpWithSpec - MaVp taking MaSpec moving averages with custom type specific parameters
+func MaVpWithSpec(inReal []float64, inPeriods []float64, inMinPeriod int, inMaxPeriod int, inMASpec MaSpec) []float64 {
 
 	outReal := make([]float64, len(inReal))
-	startIdx := inMaxPeriod - 1
+	startIdx := maLookback(inMaxPeriod, inMASpec)
 	outputSize := len(inReal)
 
 	localPeriodArray := make([]float64, outputSize)
@@ -785,7 +1476,7 @@ func MaVp(inReal []float64, inPeriods []float64, inMinPeriod int, inMaxPeriod in
 	for i := startIdx; i < outputSize; i++ {
 		curPeriod := int(localPeriodArray[i])
 		if curPeriod != 0 {
-			localOutputArray := Ma(inReal, curPeriod, inMAType)
+			localOutputArray := MaWithSpec(inReal, curPeriod, inMASpec)
 			outReal[i] = localOutputArray[i]
 			for j := i + 1; j < outputSize; j++ {
 				if localPeriodArray[j] == float64(curPeriod) {
@@ -798,6 +1489,36 @@ func MaVp(inReal []float64, inPeriods []float64, inMinPeriod int, inMaxPeriod in
 	return outReal
 }
 
+// McGinleyDynamic - McGinley Dynamic
+// md = md[-1] + (price - md[-1]) / (timeperiod * (price/md[-1])^4), seeded with the Sma of the first period
+func McGinleyDynamic(inReal []float64, inTimePeriod int) []float64 {
+
+	outReal := make([]float64, len(inReal))
+
+	if inTimePeriod < 1 || inTimePeriod > len(inReal) {
+		return outReal
+	}
+
+	periodTotal := 0.0
+	for i := 0; i < inTimePeriod; i++ {
+		periodTotal += inReal[i]
+	}
+	prevMD := periodTotal / float64(inTimePeriod)
+	outReal[inTimePeriod-1] = prevMD
+	for today := inTimePeriod; today < len(inReal); today++ {
+		tempReal := inReal[today]
+		if prevMD != 0.0 {
+			ratio := tempReal / prevMD
+			ratio *= ratio
+			prevMD += (tempReal - prevMD) / (float64(inTimePeriod) * ratio * ratio)
+		} else {
+			prevMD = tempReal
+		}
+		outReal[today] = prevMD
+	}
+	return outReal
+}
+
 // MidPoint - MidPoint over period
 func MidPoint(inReal []float64, inTimePeriod int) []float64 {
 
@@ -992,13 +1713,34 @@ func SarExt(inHigh []float64, inLow []float64,
 	inAccelerationShort float64,
 	inAccelerationMaxShort float64) []float64 {
 
-	outReal := make([]float64, len(inHigh))
+	outReal, _, _ := SarExtDetail(inHigh, inLow, inStartValue, inOffsetOnReverse, inAccelerationInitLong, inAccelerationLong,
+		inAccelerationMaxLong, inAccelerationInitShort, inAccelerationShort, inAccelerationMaxShort)
+	return outReal
+}
 
-	startIdx := 1
-	afLong := inAccelerationInitLong
-	afShort := inAccelerationInitShort
-	if afLong > inAccelerationMaxLong {
-		afLong, inAccelerationInitLong = inAccelerationMaxLong, inAccelerationMaxLong
+// SarExtDetail - Parabolic SAR - Extended, with the acceleration factor and extreme point of every bar
+// real, af, ep = SarExtDetail(high, low, startvalue=0, offsetonreverse=0, accelerationinitlong=0, accelerationlong=0, accelerationmaxlong=0, accelerationinitshort=0, accelerationshort=0, accelerationmaxshort=0)
+//
+// af and ep are those in force once the bar is processed, i.e. the ones projecting the SAR of the next bar.
+func SarExtDetail(inHigh []float64, inLow []float64,
+	inStartValue float64,
+	inOffsetOnReverse float64,
+	inAccelerationInitLong float64,
+	inAccelerationLong float64,
+	inAccelerationMaxLong float64,
+	inAccelerationInitShort float64,
+	inAccelerationShort float64,
+	inAccelerationMaxShort float64) ([]float64, []float64, []float64) {
+
+	outReal := make([]float64, len(inHigh))
+	outAF := make([]float64, len(inHigh))
+	outEP := make([]float64, len(inHigh))
+
+	startIdx := 1
+	afLong := inAccelerationInitLong
+	afShort := inAccelerationInitShort
+	if afLong > inAccelerationMaxLong {
+		afLong, inAccelerationInitLong = inAccelerationMaxLong, inAccelerationMaxLong
 	}
 
 	if inAccelerationLong > inAccelerationMaxLong {
@@ -1140,8 +1882,14 @@ func SarExt(inHigh []float64, inLow []float64,
 				}
 			}
 		}
+		if isLong == 1 {
+			outAF[outIdx-1] = afLong
+		} else {
+			outAF[outIdx-1] = afShort
+		}
+		outEP[outIdx-1] = ep
 	}
-	return outReal
+	return outReal, outAF, outEP
 }
 
 // Sma - Simple Moving Average
@@ -1175,6 +1923,50 @@ func Sma(inReal []float64, inTimePeriod int) []float64 {
 	return outReal
 }
 
+// Smma - Smoothed Moving Average (Wilder's RMA), seeded with the Sma of the first period
+func Smma(inReal []float64, inTimePeriod int) []float64 {
+
+	outReal := make([]float64, len(inReal))
+
+	if inTimePeriod < 1 || inTimePeriod > len(inReal) {
+		return outReal
+	}
+
+	inTimePeriodF := float64(inTimePeriod)
+	periodTotal := 0.0
+	for i := 0; i < inTimePeriod; i++ {
+		periodTotal += inReal[i]
+	}
+	prevMA := periodTotal / inTimePeriodF
+	outReal[inTimePeriod-1] = prevMA
+	for today := inTimePeriod; today < len(inReal); today++ {
+		prevMA = ((prevMA * (inTimePeriodF - 1)) + inReal[today]) / inTimePeriodF
+		outReal[today] = prevMA
+	}
+	return outReal
+}
+
+// Starc - Stoller Average Range Channel: Sma of close +/- multiplier * Atr
+// upperband, middleband, lowerband = Starc(high, low, close, timeperiod=6, atrperiod=15, multiplier=2)
+func Starc(inHigh []float64, inLow []float64, inClose []float64, inTimePeriod int, inAtrPeriod int, inMultiplier float64) ([]float64, []float64, []float64) {
+
+	if inTimePeriod < 1 {
+		return make([]float64, len(inClose)), make([]float64, len(inClose)), make([]float64, len(inClose))
+	}
+	return atrBands(inHigh, inLow, inClose, Sma(inClose, inTimePeriod), inTimePeriod-1, inAtrPeriod, inMultiplier)
+}
+
+// SuperSmoother - Ehlers two pole Super Smoother filter
+// real = SuperSmoother(close, timeperiod=10)
+func SuperSmoother(inReal []float64, inTimePeriod int) []float64 {
+
+	if inTimePeriod < 1 {
+		return make([]float64, len(inReal))
+	}
+	b, a := superSmootherCoefficients(float64(inTimePeriod))
+	return iirFilter(inReal, b, a, inReal, 2)
+}
+
 // T3 - Triple Exponential Moving Average (T3) (lookback=6*inTimePeriod)
 func T3(inReal []float64, inTimePeriod int, inVFactor float64) []float64 {
 
@@ -1396,6 +2188,37 @@ func Trima(inReal []float64, inTimePeriod int) []float64 {
 	return outReal
 }
 
+// Vwma - Volume Weighted Moving Average
+func Vwma(inReal []float64, inVolume []float64, inTimePeriod int) []float64 {
+
+	outReal := make([]float64, len(inReal))
+
+	if inTimePeriod < 1 || len(inVolume) < len(inReal) {
+		return outReal
+	}
+
+	sumPV := 0.0
+	sumV := 0.0
+	trailingIdx := 0
+	for today := 0; today < len(inReal); today++ {
+		sumPV += inReal[today] * inVolume[today]
+		sumV += inVolume[today]
+		if today >= inTimePeriod {
+			sumPV -= inReal[trailingIdx] * inVolume[trailingIdx]
+			sumV -= inVolume[trailingIdx]
+			trailingIdx++
+		}
+		if today >= inTimePeriod-1 {
+			if sumV > 0.00000000000001 {
+				outReal[today] = sumPV / sumV
+			} else {
+				outReal[today] = 0.0
+			}
+		}
+	}
+	return outReal
+}
+
 // Wma - Weighted Moving Average
 func Wma(inReal []float64, inTimePeriod int) []float64 {
 
@@ -1437,6 +2260,28 @@ func Wma(inReal []float64, inTimePeriod int) []float64 {
 	return outReal
 }
 
+// Zlema - Zero Lag Exponential Moving Average: Ema of (2*price - price[lag]), lag = (timeperiod-1)/2
+func Zlema(inReal []float64, inTimePeriod int) []float64 {
+
+	outReal := make([]float64, len(inReal))
+
+	lag := (inTimePeriod - 1) / 2
+	lookbackTotal := lag + (inTimePeriod - 1)
+	if inTimePeriod < 1 || lookbackTotal >= len(inReal) {
+		return outReal
+	}
+
+	tempBuffer := make([]float64, len(inReal)-lag)
+	for i, today := 0, lag; today < len(inReal); i, today = i+1, today+1 {
+		tempBuffer[i] = (2.0 * inReal[today]) - inReal[today-lag]
+	}
+	tempBuffer = Ema(tempBuffer, inTimePeriod)
+	for i, today := inTimePeriod-1, lookbackTotal; today < len(inReal); i, today = i+1, today+1 {
+		outReal[today] = tempBuffer[i]
+	}
+	return outReal
+}
+
 /* Momentum Indicators */
 
 // Adx - Average Directional Movement Index
@@ -1581,12 +2426,17 @@ func AdxR(inHigh []float64, inLow []float64, inClose []float64, inTimePeriod int
 
 // Apo - Absolute Price Oscillator
 func Apo(inReal []float64, inFastPeriod int, inSlowPeriod int, inMAType MaType) []float64 {
+	return ApoWithSpec(inReal, inFastPeriod, inSlowPeriod, inMAType.Spec())
+}
+
+// ApoWithSpec - Apo taking MaSpec moving averages with custom type specific parameters
+func ApoWithSpec(inReal []float64, inFastPeriod int, inSlowPeriod int, inMASpec MaSpec) []float64 {
 
 	if inSlowPeriod < inFastPeriod {
 		inSlowPeriod, inFastPeriod = inFastPeriod, inSlowPeriod
 	}
-	tempBuffer := Ma(inReal, inFastPeriod, inMAType)
-	outReal := Ma(inReal, inSlowPeriod, inMAType)
+	tempBuffer := MaWithSpec(inReal, inFastPeriod, inMASpec)
+	outReal := MaWithSpec(inReal, inSlowPeriod, inMASpec)
 	for i := inSlowPeriod - 1; i < len(inReal); i++ {
 		outReal[i] = tempBuffer[i] - outReal[i]
 	}
@@ -1717,6 +2567,30 @@ func AroonOsc(inHigh []float64, inLow []float64, inTimePeriod int) []float64 {
 	return outReal
 }
 
+// Accelerator - Bill Williams Accelerator Oscillator: Awesome minus its simple moving average
+// real = Accelerator(high, low, fastperiod=5, slowperiod=34, signalperiod=5)
+func Accelerator(inHigh []float64, inLow []float64, inFastPeriod int, inSlowPeriod int, inSignalPeriod int) []float64 {
+
+	awesome := Awesome(inHigh, inLow, inFastPeriod, inSlowPeriod)
+	startIdx := inSlowPeriod - 1
+	if inFastPeriod > inSlowPeriod {
+		startIdx = inFastPeriod - 1
+	}
+	outReal := maFrom(awesome, startIdx, inSignalPeriod, SMA.Spec())
+	for today := startIdx + inSignalPeriod - 1; today < len(outReal); today++ {
+		outReal[today] = awesome[today] - outReal[today]
+	}
+	return outReal
+}
+
+// Awesome - Bill Williams Awesome Oscillator: fast minus slow simple moving average of the median price
+// real = Awesome(high, low, fastperiod=5, slowperiod=34)
+func Awesome(inHigh []float64, inLow []float64, inFastPeriod int, inSlowPeriod int) []float64 {
+
+	medPrice := MedPrice(inHigh, inLow)
+	return Apo(medPrice, inFastPeriod, inSlowPeriod, SMA)
+}
+
 // Bop - Balance Of Power
 func Bop(inOpen []float64, inHigh []float64, inLow []float64, inClose []float64) []float64 {
 
@@ -1869,6 +2743,104 @@ func Cci(inHigh []float64, inLow []float64, inClose []float64, inTimePeriod int)
 	return outReal
 }
 
+// Chop - Choppiness Index: 100 * log10(sum of true ranges / range of the period) / log10(timeperiod)
+// Near 100 the market churns sideways, near 0 it trends.
+func Chop(inHigh []float64, inLow []float64, inClose []float64, inTimePeriod int) []float64 {
+
+	outReal := make([]float64, len(inClose))
+
+	if inTimePeriod < 2 || inTimePeriod >= len(inClose) {
+		return outReal
+	}
+	sumTrueRange := Sum(TRange(inHigh, inLow, inClose), inTimePeriod)
+	highest := Max(inHigh, inTimePeriod)
+	lowest := Min(inLow, inTimePeriod)
+	tempReal := math.Log10(float64(inTimePeriod))
+	for today := inTimePeriod; today < len(inClose); today++ {
+		if tempRange := highest[today] - lowest[today]; tempRange > 0.0 && sumTrueRange[today] > 0.0 {
+			outReal[today] = 100.0 * math.Log10(sumTrueRange[today]/tempRange) / tempReal
+		}
+	}
+	return outReal
+}
+
+// ConnorsRsi - Connors RSI: average of a short Rsi, an Rsi of the up/down streak length
+// and the PercentRank of the one bar rate of change
+// real = ConnorsRsi(close, rsiperiod=3, streakperiod=2, rankperiod=100)
+func ConnorsRsi(inReal []float64, inRsiPeriod int, inStreakPeriod int, inRankPeriod int) []float64 {
+
+	outReal := make([]float64, len(inReal))
+
+	startIdx := inRankPeriod + 1
+	if inRsiPeriod > startIdx {
+		startIdx = inRsiPeriod
+	}
+	if inStreakPeriod > startIdx {
+		startIdx = inStreakPeriod
+	}
+	if inRsiPeriod < 2 || inStreakPeriod < 2 || startIdx >= len(inReal) {
+		return outReal
+	}
+
+	streak := make([]float64, len(inReal))
+	for today := 1; today < len(inReal); today++ {
+		if inReal[today] > inReal[today-1] {
+			streak[today] = math.Max(streak[today-1], 0.0) + 1.0
+		} else if inReal[today] < inReal[today-1] {
+			streak[today] = math.Min(streak[today-1], 0.0) - 1.0
+		}
+	}
+	rsi := Rsi(inReal, inRsiPeriod)
+	streakRsi := Rsi(streak, inStreakPeriod)
+	rank := PercentRank(Roc(inReal, 1)[1:], inRankPeriod)
+	for today := startIdx; today < len(inReal); today++ {
+		outReal[today] = (rsi[today] + streakRsi[today] + rank[today-1]) / 3.0
+	}
+	return outReal
+}
+
+// Coppock - Coppock Curve: weighted moving average of the sum of two rates of change
+// real = Coppock(close, longrocperiod=14, shortrocperiod=11, wmaperiod=10)
+func Coppock(inReal []float64, inLongRocPeriod int, inShortRocPeriod int, inWmaPeriod int) []float64 {
+
+	startIdx := inLongRocPeriod
+	if inShortRocPeriod > startIdx {
+		startIdx = inShortRocPeriod
+	}
+	longRoc := Roc(inReal, inLongRocPeriod)
+	shortRoc := Roc(inReal, inShortRocPeriod)
+	for today := startIdx; today < len(inReal); today++ {
+		longRoc[today] += shortRoc[today]
+	}
+	return maFrom(longRoc, startIdx, inWmaPeriod, WMA.Spec())
+}
+
+// CutlerRsi - Cutler's RSI, averaging gains and losses with a simple moving average instead of Wilder's smoothing
+func CutlerRsi(inReal []float64, inTimePeriod int) []float64 {
+	return RsiMa(inReal, inTimePeriod, SMA)
+}
+
+// Dpo - Detrended Price Oscillator: the price timeperiod/2+1 bars ago minus the current simple moving average
+// real = Dpo(close, timeperiod=20)
+func Dpo(inReal []float64, inTimePeriod int) []float64 {
+
+	outReal := make([]float64, len(inReal))
+
+	shift := (inTimePeriod / 2) + 1
+	startIdx := inTimePeriod - 1
+	if shift > startIdx {
+		startIdx = shift
+	}
+	if inTimePeriod < 1 || startIdx >= len(inReal) {
+		return outReal
+	}
+	sma := Sma(inReal, inTimePeriod)
+	for today := startIdx; today < len(inReal); today++ {
+		outReal[today] = inReal[today-shift] - sma[today]
+	}
+	return outReal
+}
+
 // Dx - Directional Movement Index
 func Dx(inHigh []float64, inLow []float64, inClose []float64, inTimePeriod int) []float64 {
 
@@ -1974,10 +2946,157 @@ func Dx(inHigh []float64, inLow []float64, inClose []float64, inTimePeriod int)
 	return outReal
 }
 
+// EfficiencyRatio - Kaufman Efficiency Ratio: net change over the sum of absolute bar to bar changes, 0..1
+// The same ratio Kama uses to adapt its smoothing constant (see KamaDetail), so a flat window gives 1.0.
+func EfficiencyRatio(inReal []float64, inTimePeriod int) []float64 {
+
+	outReal := make([]float64, len(inReal))
+
+	if inTimePeriod < 1 || inTimePeriod >= len(inReal) {
+		return outReal
+	}
+	sumROC1 := 0.0
+	for today := 1; today <= inTimePeriod; today++ {
+		sumROC1 += math.Abs(inReal[today] - inReal[today-1])
+	}
+	for today := inTimePeriod; today < len(inReal); today++ {
+		if today > inTimePeriod {
+			sumROC1 += math.Abs(inReal[today]-inReal[today-1]) - math.Abs(inReal[today-inTimePeriod]-inReal[today-inTimePeriod-1])
+		}
+		outReal[today] = kamaEfficiencyRatio(inReal[today]-inReal[today-inTimePeriod], sumROC1)
+	}
+	return outReal
+}
+
+// Fisher - Ehlers Fisher Transform of the median price and its trigger (the previous value)
+// fisher, trigger = Fisher(high, low, timeperiod=10)
+func Fisher(inHigh []float64, inLow []float64, inTimePeriod int) ([]float64, []float64) {
+
+	outFisher := make([]float64, len(inHigh))
+	outTrigger := make([]float64, len(inHigh))
+
+	if inTimePeriod < 1 {
+		return outFisher, outTrigger
+	}
+
+	medPrice := MedPrice(inHigh, inLow)
+	lowest, highest := MinMax(medPrice, inTimePeriod)
+	value, fisher := 0.0, 0.0
+	for today := inTimePeriod - 1; today < len(inHigh); today++ {
+		tempReal := 0.0
+		if highest[today] > lowest[today] {
+			tempReal = ((medPrice[today] - lowest[today]) / (highest[today] - lowest[today])) - 0.5
+		}
+		value = (0.66 * tempReal) + (0.67 * value)
+		value = math.Max(-0.999, math.Min(0.999, value))
+		outTrigger[today] = fisher
+		fisher = (0.5 * math.Log((1.0+value)/(1.0-value))) + (0.5 * fisher)
+		outFisher[today] = fisher
+	}
+	return outFisher, outTrigger
+}
+
+// Imi - Intraday Momentum Index
+func Imi(inOpen []float64, inClose []float64, inTimePeriod int) []float64 {
+
+	outReal := make([]float64, len(inClose))
+
+	if inTimePeriod < 2 {
+		return outReal
+	}
+
+	lookbackTotal := inTimePeriod - 1
+	for today := lookbackTotal; today < len(inClose); today++ {
+		upsum := 0.0
+		downsum := 0.0
+		for i := today - lookbackTotal; i <= today; i++ {
+			close := inClose[i]
+			open := inOpen[i]
+			if close > open {
+				upsum += (close - open)
+			} else {
+				downsum += (open - close)
+			}
+		}
+		tempReal := upsum + downsum
+		if !((-0.00000000000001 < tempReal) && (tempReal < 0.00000000000001)) {
+			outReal[today] = 100.0 * (upsum / tempReal)
+		} else {
+			outReal[today] = 0.0
+		}
+	}
+	return outReal
+}
+
+// InverseFisher - Inverse Fisher Transform: (exp(2x)-1)/(exp(2x)+1), squashing the input into -1..1
+// Oscillators are usually rescaled first, e.g. 0.1*(Rsi-50) smoothed by a Wma.
+func InverseFisher(inReal []float64) []float64 {
+
+	outReal := make([]float64, len(inReal))
+	for i := 0; i < len(inReal); i++ {
+		outReal[i] = math.Tanh(inReal[i])
+	}
+	return outReal
+}
+
+// Kst - Pring's Know Sure Thing: weighted sum of four smoothed rates of change and its signal line
+// kst, signal = Kst(close, rocperiod1=10, rocperiod2=15, rocperiod3=20, rocperiod4=30, smaperiod1=10, smaperiod2=10, smaperiod3=10, smaperiod4=15, signalperiod=9)
+func Kst(inReal []float64, inRocPeriod1 int, inRocPeriod2 int, inRocPeriod3 int, inRocPeriod4 int, inSmaPeriod1 int, inSmaPeriod2 int, inSmaPeriod3 int, inSmaPeriod4 int, inSignalPeriod int) ([]float64, []float64) {
+
+	outKst := make([]float64, len(inReal))
+
+	rocPeriods := [4]int{inRocPeriod1, inRocPeriod2, inRocPeriod3, inRocPeriod4}
+	smaPeriods := [4]int{inSmaPeriod1, inSmaPeriod2, inSmaPeriod3, inSmaPeriod4}
+	startIdx := 0
+	for i := range rocPeriods {
+		if lookback := rocPeriods[i] + smaPeriods[i] - 1; lookback > startIdx {
+			startIdx = lookback
+		}
+	}
+	for i := range rocPeriods {
+		rcma := maFrom(Roc(inReal, rocPeriods[i]), rocPeriods[i], smaPeriods[i], SMA.Spec())
+		for today := startIdx; today < len(inReal); today++ {
+			outKst[today] += float64(i+1) * rcma[today]
+		}
+	}
+	outSignal := maFrom(outKst, startIdx, inSignalPeriod, SMA.Spec())
+	return outKst, outSignal
+}
+
+// LaguerreRsi - Ehlers Laguerre RSI, an RSI over the four Laguerre filter elements, scaled 0..100 as Rsi
+// real = LaguerreRsi(close, gamma=0.5)
+func LaguerreRsi(inReal []float64, inGamma float64) []float64 {
+
+	outReal := make([]float64, len(inReal))
+	l0, l1, l2, l3 := laguerre(inReal, inGamma)
+	for today := range inReal {
+		up, down := 0.0, 0.0
+		for _, pair := range [3][2]float64{{l0[today], l1[today]}, {l1[today], l2[today]}, {l2[today], l3[today]}} {
+			if pair[0] >= pair[1] {
+				up += pair[0] - pair[1]
+			} else {
+				down += pair[1] - pair[0]
+			}
+		}
+		if up+down != 0.0 {
+			outReal[today] = 100.0 * up / (up + down)
+		}
+	}
+	return outReal
+}
+
 // Macd - Moving Average Convergence/Divergence
 // unstable period ~= 100
 func Macd(inReal []float64, inFastPeriod int, inSlowPeriod int, inSignalPeriod int) ([]float64, []float64, []float64) {
 
+	outMACD, outMACDSignal, outMACDHist, _, _ := MacdDetail(inReal, inFastPeriod, inSlowPeriod, inSignalPeriod)
+	return outMACD, outMACDSignal, outMACDHist
+}
+
+// MacdDetail - Moving Average Convergence/Divergence together with the fast and slow EMAs it is the difference of
+// macd, macdsignal, macdhist, fastema, slowema = MacdDetail(close, fastperiod=12, slowperiod=26, signalperiod=9)
+func MacdDetail(inReal []float64, inFastPeriod int, inSlowPeriod int, inSignalPeriod int) ([]float64, []float64, []float64, []float64, []float64) {
+
 	if inSlowPeriod < inFastPeriod {
 		inSlowPeriod, inFastPeriod = inFastPeriod, inSlowPeriod
 	}
@@ -2001,15 +3120,12 @@ func Macd(inReal []float64, inFastPeriod int, inSlowPeriod int, inSignalPeriod i
 	lookbackTotal := lookbackSignal
 	lookbackTotal += (inSlowPeriod - 1)
 
-	fastEMABuffer := ema(inReal, inFastPeriod, k2)
-	slowEMABuffer := ema(inReal, inSlowPeriod, k1)
-	for i := 0; i < len(fastEMABuffer); i++ {
-		fastEMABuffer[i] = fastEMABuffer[i] - slowEMABuffer[i]
-	}
+	outFastEMA := ema(inReal, inFastPeriod, k2)
+	outSlowEMA := ema(inReal, inSlowPeriod, k1)
 
 	outMACD := make([]float64, len(inReal))
-	for i := lookbackTotal - 1; i < len(fastEMABuffer); i++ {
-		outMACD[i] = fastEMABuffer[i]
+	for i := lookbackTotal - 1; i < len(outFastEMA); i++ {
+		outMACD[i] = outFastEMA[i] - outSlowEMA[i]
 	}
 	outMACDSignal := ema(outMACD, inSignalPeriod, (2.0 / float64(inSignalPeriod+1)))
 
@@ -2018,12 +3134,17 @@ func Macd(inReal []float64, inFastPeriod int, inSlowPeriod int, inSignalPeriod i
 		outMACDHist[i] = outMACD[i] - outMACDSignal[i]
 	}
 
-	return outMACD, outMACDSignal, outMACDHist
+	return outMACD, outMACDSignal, outMACDHist, outFastEMA, outSlowEMA
 }
 
 // MacdExt - MACD with controllable MA type
 // unstable period ~= 100
 func MacdExt(inReal []float64, inFastPeriod int, inFastMAType MaType, inSlowPeriod int, inSlowMAType MaType, inSignalPeriod int, inSignalMAType MaType) ([]float64, []float64, []float64) {
+	return MacdExtWithSpec(inReal, inFastPeriod, inFastMAType.Spec(), inSlowPeriod, inSlowMAType.Spec(), inSignalPeriod, inSignalMAType.Spec())
+}
+
+// MacdExtWithSpec - MacdExt taking MaSpec moving averages with custom type specific parameters
+func MacdExtWithSpec(inReal []float64, inFastPeriod int, inFastMASpec MaSpec, inSlowPeriod int, inSlowMASpec MaSpec, inSignalPeriod int, inSignalMASpec MaSpec) ([]float64, []float64, []float64) {
 
 	lookbackLargest := 0
 	if inFastPeriod < inSlowPeriod {
@@ -2037,14 +3158,14 @@ func MacdExt(inReal []float64, inFastPeriod int, inFastMAType MaType, inSlowPeri
 	outMACDSignal := make([]float64, len(inReal))
 	outMACDHist := make([]float64, len(inReal))
 
-	slowMABuffer := Ma(inReal, inSlowPeriod, inSlowMAType)
-	fastMABuffer := Ma(inReal, inFastPeriod, inFastMAType)
+	slowMABuffer := MaWithSpec(inReal, inSlowPeriod, inSlowMASpec)
+	fastMABuffer := MaWithSpec(inReal, inFastPeriod, inFastMASpec)
 	tempBuffer1 := make([]float64, len(inReal))
 
 	for i := 0; i < len(slowMABuffer); i++ {
 		tempBuffer1[i] = fastMABuffer[i] - slowMABuffer[i]
 	}
-	tempBuffer2 := Ma(tempBuffer1, inSignalPeriod, inSignalMAType)
+	tempBuffer2 := MaWithSpec(tempBuffer1, inSignalPeriod, inSignalMASpec)
 
 	for i := lookbackTotal; i < len(outMACDHist); i++ {
 		outMACD[i] = tempBuffer1[i]
@@ -2061,6 +3182,33 @@ func MacdFix(inReal []float64, inSignalPeriod int) ([]float64, []float64, []floa
 	return Macd(inReal, 0, 0, inSignalPeriod)
 }
 
+// MassIndex - Dorsey's Mass Index: sum over sumperiod of Ema(high-low) / Ema(Ema(high-low))
+// real = MassIndex(high, low, emaperiod=9, sumperiod=25)
+func MassIndex(inHigh []float64, inLow []float64, inEmaPeriod int, inSumPeriod int) []float64 {
+
+	outReal := make([]float64, len(inHigh))
+
+	highLow := make([]float64, len(inHigh))
+	for today := range inHigh {
+		highLow[today] = inHigh[today] - inLow[today]
+	}
+	startIdx := inEmaPeriod - 1
+	single := maFrom(highLow, 0, inEmaPeriod, EMA.Spec())
+	double := maFrom(single, startIdx, inEmaPeriod, EMA.Spec())
+	startIdx += inEmaPeriod - 1
+	if inSumPeriod < 1 || startIdx+inSumPeriod-1 >= len(inHigh) {
+		return outReal
+	}
+	ratio := make([]float64, len(inHigh))
+	for today := startIdx; today < len(inHigh); today++ {
+		if double[today] != 0.0 {
+			ratio[today] = single[today] / double[today]
+		}
+	}
+	copy(outReal[startIdx+inSumPeriod-1:], Sum(ratio[startIdx:], inSumPeriod)[inSumPeriod-1:])
+	return outReal
+}
+
 // MinusDI - Minus Directional Indicator
 func MinusDI(inHigh []float64, inLow []float64, inClose []float64, inTimePeriod int) []float64 {
 
@@ -2679,14 +3827,44 @@ func PlusDM(inHigh []float64, inLow []float64, inTimePeriod int) []float64 {
 	return outReal
 }
 
+// Pmo - DecisionPoint Price Momentum Oscillator and its signal line
+// pmo, signal = Pmo(close, firstperiod=35, secondperiod=20, signalperiod=10)
+//
+// The one bar rate of change is smoothed twice by custom EMAs with k = 2/period (not 2/(period+1)),
+// scaled by 10 in between; the signal is a regular Ema of the PMO.
+func Pmo(inReal []float64, inFirstPeriod int, inSecondPeriod int, inSignalPeriod int) ([]float64, []float64) {
+
+	outPmo := make([]float64, len(inReal))
+	outSignal := make([]float64, len(inReal))
+
+	startIdx := inFirstPeriod + inSecondPeriod - 1
+	if inFirstPeriod < 1 || inSecondPeriod < 1 || startIdx >= len(inReal) {
+		return outPmo, outSignal
+	}
+	roc := Roc(inReal, 1)
+	tempBuffer := ema(roc[1:], inFirstPeriod, 2.0/float64(inFirstPeriod))
+	for i := range tempBuffer {
+		tempBuffer[i] *= 10.0
+	}
+	tempBuffer = ema(tempBuffer[inFirstPeriod-1:], inSecondPeriod, 2.0/float64(inSecondPeriod))
+	copy(outPmo[startIdx:], tempBuffer[inSecondPeriod-1:])
+	outSignal = maFrom(outPmo, startIdx, inSignalPeriod, EMA.Spec())
+	return outPmo, outSignal
+}
+
 // Ppo - Percentage Price Oscillator
 func Ppo(inReal []float64, inFastPeriod int, inSlowPeriod int, inMAType MaType) []float64 {
+	return PpoWithSpec(inReal, inFastPeriod, inSlowPeriod, inMAType.Spec())
+}
+
+// PpoWithSpec - Ppo taking MaSpec moving averages with custom type specific parameters
+func PpoWithSpec(inReal []float64, inFastPeriod int, inSlowPeriod int, inMASpec MaSpec) []float64 {
 
 	if inSlowPeriod < inFastPeriod {
 		inSlowPeriod, inFastPeriod = inFastPeriod, inSlowPeriod
 	}
-	tempBuffer := Ma(inReal, inFastPeriod, inMAType)
-	outReal := Ma(inReal, inSlowPeriod, inMAType)
+	tempBuffer := MaWithSpec(inReal, inFastPeriod, inMASpec)
+	outReal := MaWithSpec(inReal, inSlowPeriod, inMASpec)
 
 	for i := inSlowPeriod - 1; i < len(inReal); i++ {
 		tempReal := outReal[i]
@@ -2890,8 +4068,113 @@ func Rsi(inReal []float64, inTimePeriod int) []float64 {
 	return outReal
 }
 
+// RsiMa - Relative strength index with gains and losses averaged by the given moving average
+// real = RsiMa(close, timeperiod=14, matype=SMMA)
+//
+// SMMA reproduces Rsi's Wilder smoothing, EMA gives the EMA RSI and SMA Cutler's RSI.
+func RsiMa(inReal []float64, inTimePeriod int, inMAType MaType) []float64 {
+
+	outReal := make([]float64, len(inReal))
+
+	if inTimePeriod < 2 {
+		return outReal
+	}
+	gains := make([]float64, len(inReal))
+	losses := make([]float64, len(inReal))
+	for today := 1; today < len(inReal); today++ {
+		tempReal := inReal[today] - inReal[today-1]
+		if tempReal < 0 {
+			losses[today] = -tempReal
+		} else {
+			gains[today] = tempReal
+		}
+	}
+	gains = maFrom(gains, 1, inTimePeriod, inMAType.Spec())
+	losses = maFrom(losses, 1, inTimePeriod, inMAType.Spec())
+	for today := 1 + maLookback(inTimePeriod, inMAType.Spec()); today < len(inReal); today++ {
+		tempReal := gains[today] + losses[today]
+		if !((-0.00000000000001 < tempReal) && (tempReal < 0.00000000000001)) {
+			outReal[today] = 100.0 * (gains[today] / tempReal)
+		}
+	}
+	return outReal
+}
+
+// Rwi - Poulos' Random Walk Index of the high and of the low
+// rwihigh, rwilow = Rwi(high, low, close, timeperiod=14)
+//
+// The move since n bars ago in units of the distance a random walk would cover, atr(n)*sqrt(n),
+// taking the largest over n = 2..timeperiod; atr(n) is the simple average true range of the last n bars.
+func Rwi(inHigh []float64, inLow []float64, inClose []float64, inTimePeriod int) ([]float64, []float64) {
+
+	outHigh := make([]float64, len(inClose))
+	outLow := make([]float64, len(inClose))
+
+	if inTimePeriod < 2 || inTimePeriod >= len(inClose) {
+		return outHigh, outLow
+	}
+	trueRange := TRange(inHigh, inLow, inClose)
+	cumulative := make([]float64, len(inClose))
+	for today := 1; today < len(inClose); today++ {
+		cumulative[today] = cumulative[today-1] + trueRange[today]
+	}
+	for today := inTimePeriod; today < len(inClose); today++ {
+		for n := 2; n <= inTimePeriod; n++ {
+			tempReal := (cumulative[today] - cumulative[today-n]) / float64(n) * math.Sqrt(float64(n))
+			if tempReal <= 0.0 {
+				continue
+			}
+			outHigh[today] = math.Max(outHigh[today], (inHigh[today]-inLow[today-n])/tempReal)
+			outLow[today] = math.Max(outLow[today], (inHigh[today-n]-inLow[today])/tempReal)
+		}
+	}
+	return outHigh, outLow
+}
+
+// Smi - Blau's Stochastic Momentum Index and its signal line
+// smi, signal = Smi(high, low, close, timeperiod=10, smoothperiod=3, doublesmoothperiod=3, signalperiod=10)
+//
+// The distance of the close to the midpoint of the timeperiod range, double smoothed by Ema and divided by
+// half the equally smoothed range; -100..100.
+func Smi(inHigh []float64, inLow []float64, inClose []float64, inTimePeriod int, inSmoothPeriod int, inDoubleSmoothPeriod int, inSignalPeriod int) ([]float64, []float64) {
+
+	outSmi := make([]float64, len(inClose))
+
+	startIdx := inTimePeriod - 1
+	if inTimePeriod < 1 || startIdx >= len(inClose) {
+		return outSmi, make([]float64, len(inClose))
+	}
+	highest := Max(inHigh, inTimePeriod)
+	lowest := Min(inLow, inTimePeriod)
+	distance := make([]float64, len(inClose))
+	rangeWidth := make([]float64, len(inClose))
+	for today := startIdx; today < len(inClose); today++ {
+		distance[today] = inClose[today] - ((highest[today] + lowest[today]) / 2.0)
+		rangeWidth[today] = highest[today] - lowest[today]
+	}
+
+	distance = maFrom(distance, startIdx, inSmoothPeriod, EMA.Spec())
+	rangeWidth = maFrom(rangeWidth, startIdx, inSmoothPeriod, EMA.Spec())
+	startIdx += inSmoothPeriod - 1
+	distance = maFrom(distance, startIdx, inDoubleSmoothPeriod, EMA.Spec())
+	rangeWidth = maFrom(rangeWidth, startIdx, inDoubleSmoothPeriod, EMA.Spec())
+	startIdx += inDoubleSmoothPeriod - 1
+	for today := startIdx; today < len(inClose); today++ {
+		if rangeWidth[today] != 0.0 {
+			outSmi[today] = 100.0 * distance[today] / (0.5 * rangeWidth[today])
+		}
+	}
+	outSignal := maFrom(outSmi, startIdx, inSignalPeriod, EMA.Spec())
+	return outSmi, outSignal
+}
+
 // Stoch - Stochastic
 func Stoch(inHigh []float64, inLow []float64, inClose []float64, inFastKPeriod int, inSlowKPeriod int, inSlowKMAType MaType, inSlowDPeriod int, inSlowDMAType MaType) ([]float64, []float64) {
+	return StochWithSpec(inHigh, inLow, inClose, inFastKPeriod, inSlowKPeriod, inSlowKMAType.Spec(), inSlowDPeriod, inSlowDMAType.Spec())
+}
+
+// StochWithSpec - Stoch taking MaSpec moving averages with custom type specific parameters
+func StochWithSpec(inHigh []float64, inLow []float64, inClose []float64, inFastKPeriod int, inSlowKPeriod int, inSlowKMASpec MaSpec, inSlowDPeriod int, inSlowDMASpec MaSpec) ([]float64, []float64) {
 
 	outSlowK := make([]float64, len(inClose))
 	outSlowD := make([]float64, len(inClose))
@@ -2957,8 +4240,8 @@ func Stoch(inHigh []float64, inLow []float64, inClose []float64, inFastKPeriod i
 		today++
 	}
 
-	tempBuffer1 := Ma(tempBuffer, inSlowKPeriod, inSlowKMAType)
-	tempBuffer2 := Ma(tempBuffer1, inSlowDPeriod, inSlowDMAType)
+	tempBuffer1 := MaWithSpec(tempBuffer, inSlowKPeriod, inSlowKMASpec)
+	tempBuffer2 := MaWithSpec(tempBuffer1, inSlowDPeriod, inSlowDMASpec)
 	//for i, j := lookbackK, lookbackTotal; j < len(inClose); i, j = i+1, j+1 {
 	for i, j := lookbackDSlow+lookbackKSlow, lookbackTotal; j < len(inClose); i, j = i+1, j+1 {
 		outSlowK[j] = tempBuffer1[i]
@@ -2970,6 +4253,11 @@ func Stoch(inHigh []float64, inLow []float64, inClose []float64, inFastKPeriod i
 
 // StochF - Stochastic Fast
 func StochF(inHigh []float64, inLow []float64, inClose []float64, inFastKPeriod int, inFastDPeriod int, inFastDMAType MaType) ([]float64, []float64) {
+	return StochFWithSpec(inHigh, inLow, inClose, inFastKPeriod, inFastDPeriod, inFastDMAType.Spec())
+}
+
+// StochFWithSpec - StochF taking MaSpec moving averages with custom type specific parameters
+func StochFWithSpec(inHigh []float64, inLow []float64, inClose []float64, inFastKPeriod int, inFastDPeriod int, inFastDMASpec MaSpec) ([]float64, []float64) {
 
 	outFastK := make([]float64, len(inClose))
 	outFastD := make([]float64, len(inClose))
@@ -3037,7 +4325,7 @@ func StochF(inHigh []float64, inLow []float64, inClose []float64, inFastKPeriod
 		today++
 	}
 
-	tempBuffer1 := Ma(tempBuffer, inFastDPeriod, inFastDMAType)
+	tempBuffer1 := MaWithSpec(tempBuffer, inFastDPeriod, inFastDMASpec)
 	for i, j := lookbackFastD, lookbackTotal; j < len(inClose); i, j = i+1, j+1 {
 		outFastK[j] = tempBuffer[i]
 		outFastD[j] = tempBuffer1[i]
@@ -3046,8 +4334,33 @@ func StochF(inHigh []float64, inLow []float64, inClose []float64, inFastKPeriod
 	return outFastK, outFastD
 }
 
+// StochOsc - Fast stochastic of any single series, typically an oscillator computed beforehand
+// fastk, fastd = StochOsc(real, lookback=14, fastk_period=5, fastd_period=3, fastd_matype=SMA)
+//
+// lookback is the number of leading bars of inReal that are not yet valid (e.g. the period of an Rsi);
+// they are kept out of the highest/lowest windows and out of the fastd average.
+func StochOsc(inReal []float64, inLookback int, inFastKPeriod int, inFastDPeriod int, inFastDMAType MaType) ([]float64, []float64) {
+
+	outFastK := make([]float64, len(inReal))
+	outFastD := make([]float64, len(inReal))
+
+	if inLookback < 0 || inLookback >= len(inReal) {
+		return outFastK, outFastD
+	}
+	tempReal := inReal[inLookback:]
+	tempk, tempd := StochF(tempReal, tempReal, tempReal, inFastKPeriod, inFastDPeriod, inFastDMAType)
+	copy(outFastK[inLookback:], tempk)
+	copy(outFastD[inLookback:], tempd)
+	return outFastK, outFastD
+}
+
 // StochRsi - Stochastic Relative Strength Index
 func StochRsi(inReal []float64, inTimePeriod int, inFastKPeriod int, inFastDPeriod int, inFastDMAType MaType) ([]float64, []float64) {
+	return StochRsiWithSpec(inReal, inTimePeriod, inFastKPeriod, inFastDPeriod, inFastDMAType.Spec())
+}
+
+// StochRsiWithSpec - StochRsi taking MaSpec moving averages with custom type specific parameters
+func StochRsiWithSpec(inReal []float64, inTimePeriod int, inFastKPeriod int, inFastDPeriod int, inFastDMASpec MaSpec) ([]float64, []float64) {
 
 	outFastK := make([]float64, len(inReal))
 	outFastD := make([]float64, len(inReal))
@@ -3056,7 +4369,7 @@ func StochRsi(inReal []float64, inTimePeriod int, inFastKPeriod int, inFastDPeri
 	lookbackTotal := inTimePeriod + lookbackSTOCHF
 	startIdx := lookbackTotal
 	tempRSIBuffer := Rsi(inReal, inTimePeriod)
-	tempk, tempd := StochF(tempRSIBuffer, tempRSIBuffer, tempRSIBuffer, inFastKPeriod, inFastDPeriod, inFastDMAType)
+	tempk, tempd := StochFWithSpec(tempRSIBuffer, tempRSIBuffer, tempRSIBuffer, inFastKPeriod, inFastDPeriod, inFastDMASpec)
 
 	for i := startIdx; i < len(inReal); i++ {
 		outFastK[i] = tempk[i]
@@ -3082,8 +4395,39 @@ func Trix(inReal []float64, inTimePeriod int) []float64 {
 	return outReal
 }
 
-// UltOsc - Ultimate Oscillator
-func UltOsc(inHigh []float64, inLow []float64, inClose []float64, inTimePeriod1 int, inTimePeriod2 int, inTimePeriod3 int) []float64 {
+// Tsi - True Strength Index and its signal line
+// tsi, signal = Tsi(close, longperiod=25, shortperiod=13, signalperiod=13)
+//
+// 100 * the bar to bar momentum double smoothed by Ema over its equally smoothed absolute value.
+func Tsi(inReal []float64, inLongPeriod int, inShortPeriod int, inSignalPeriod int) ([]float64, []float64) {
+
+	outTsi := make([]float64, len(inReal))
+
+	momentum := make([]float64, len(inReal))
+	absMomentum := make([]float64, len(inReal))
+	for today := 1; today < len(inReal); today++ {
+		momentum[today] = inReal[today] - inReal[today-1]
+		absMomentum[today] = math.Abs(momentum[today])
+	}
+
+	startIdx := 1
+	momentum = maFrom(momentum, startIdx, inLongPeriod, EMA.Spec())
+	absMomentum = maFrom(absMomentum, startIdx, inLongPeriod, EMA.Spec())
+	startIdx += inLongPeriod - 1
+	momentum = maFrom(momentum, startIdx, inShortPeriod, EMA.Spec())
+	absMomentum = maFrom(absMomentum, startIdx, inShortPeriod, EMA.Spec())
+	startIdx += inShortPeriod - 1
+	for today := startIdx; today < len(inReal); today++ {
+		if absMomentum[today] != 0.0 {
+			outTsi[today] = 100.0 * momentum[today] / absMomentum[today]
+		}
+	}
+	outSignal := maFrom(outTsi, startIdx, inSignalPeriod, EMA.Spec())
+	return outTsi, outSignal
+}
+
+// UltOsc - Ultimate Oscillator
+func UltOsc(inHigh []float64, inLow []float64, inClose []float64, inTimePeriod1 int, inTimePeriod2 int, inTimePeriod3 int) []float64 {
 
 	outReal := make([]float64, len(inClose))
 
@@ -3332,6 +4676,60 @@ func UltOsc(inHigh []float64, inLow []float64, inClose []float64, inTimePeriod1
 	return outReal
 }
 
+// Vhf - Vertical Horizontal Filter: range of the closes over the sum of absolute bar to bar changes
+// Both cover the same timeperiod+1 closes, i.e. the timeperiod changes ending at the current bar.
+// High values flag a trending market, low values a congestion.
+func Vhf(inReal []float64, inTimePeriod int) []float64 {
+
+	outReal := make([]float64, len(inReal))
+
+	if inTimePeriod < 1 || inTimePeriod >= len(inReal) {
+		return outReal
+	}
+	lowest, highest := MinMax(inReal, inTimePeriod+1)
+	sumROC1 := 0.0
+	for today := 1; today <= inTimePeriod; today++ {
+		sumROC1 += math.Abs(inReal[today] - inReal[today-1])
+	}
+	for today := inTimePeriod; today < len(inReal); today++ {
+		if today > inTimePeriod {
+			sumROC1 += math.Abs(inReal[today]-inReal[today-1]) - math.Abs(inReal[today-inTimePeriod]-inReal[today-inTimePeriod-1])
+		}
+		if sumROC1 > 0.00000000000001 {
+			outReal[today] = (highest[today] - lowest[today]) / sumROC1
+		}
+	}
+	return outReal
+}
+
+// Vortex - Vortex Indicator
+// plusvi, minusvi = Vortex(high, low, close, timeperiod=14)
+func Vortex(inHigh []float64, inLow []float64, inClose []float64, inTimePeriod int) ([]float64, []float64) {
+
+	outPlus := make([]float64, len(inClose))
+	outMinus := make([]float64, len(inClose))
+
+	if inTimePeriod < 1 || inTimePeriod >= len(inClose) {
+		return outPlus, outMinus
+	}
+	plusVM := make([]float64, len(inClose))
+	minusVM := make([]float64, len(inClose))
+	for today := 1; today < len(inClose); today++ {
+		plusVM[today] = math.Abs(inHigh[today] - inLow[today-1])
+		minusVM[today] = math.Abs(inLow[today] - inHigh[today-1])
+	}
+	sumTrueRange := Sum(TRange(inHigh, inLow, inClose), inTimePeriod)
+	plusVM = Sum(plusVM, inTimePeriod)
+	minusVM = Sum(minusVM, inTimePeriod)
+	for today := inTimePeriod; today < len(inClose); today++ {
+		if sumTrueRange[today] > 0.0 {
+			outPlus[today] = plusVM[today] / sumTrueRange[today]
+			outMinus[today] = minusVM[today] / sumTrueRange[today]
+		}
+	}
+	return outPlus, outMinus
+}
+
 // WillR - Williams' %R
 func WillR(inHigh []float64, inLow []float64, inClose []float64, inTimePeriod int) []float64 {
 
@@ -3516,6 +4914,599 @@ func Obv(inReal []float64, inVolume []float64) []float64 {
 	return outReal
 }
 
+// Cmf - Chaikin Money Flow
+func Cmf(inHigh []float64, inLow []float64, inClose []float64, inVolume []float64, inTimePeriod int) []float64 {
+
+	outReal := make([]float64, len(inClose))
+
+	if inTimePeriod < 1 {
+		return outReal
+	}
+
+	sumMFV := 0.0
+	sumV := 0.0
+	trailingIdx := 0
+	for today := 0; today < len(inClose); today++ {
+		sumMFV += moneyFlowVolume(inHigh[today], inLow[today], inClose[today], inVolume[today])
+		sumV += inVolume[today]
+		if today >= inTimePeriod {
+			sumMFV -= moneyFlowVolume(inHigh[trailingIdx], inLow[trailingIdx], inClose[trailingIdx], inVolume[trailingIdx])
+			sumV -= inVolume[trailingIdx]
+			trailingIdx++
+		}
+		if today >= inTimePeriod-1 {
+			if sumV > 0.00000000000001 {
+				outReal[today] = sumMFV / sumV
+			} else {
+				outReal[today] = 0.0
+			}
+		}
+	}
+	return outReal
+}
+
+// moneyFlowVolume - close location value times volume, as accumulated by Ad
+func moneyFlowVolume(high float64, low float64, close float64, volume float64) float64 {
+	tmp := high - low
+	if tmp > 0.0 {
+		return (((close - low) - (high - close)) / tmp) * volume
+	}
+	return 0.0
+}
+
+// Eom - Ease of Movement, smoothed with the given moving average
+// real = Eom(high, low, volume, timeperiod=14, matype=SMA, scale=100000000)
+func Eom(inHigh []float64, inLow []float64, inVolume []float64, inTimePeriod int, inMAType MaType, inScale float64) []float64 {
+
+	tempBuffer := make([]float64, len(inHigh))
+	for today := 1; today < len(inHigh); today++ {
+		distance := ((inHigh[today] + inLow[today]) / 2.0) - ((inHigh[today-1] + inLow[today-1]) / 2.0)
+		if inVolume[today] > 0.00000000000001 {
+			tempBuffer[today] = distance * (inHigh[today] - inLow[today]) * inScale / inVolume[today]
+		} else {
+			tempBuffer[today] = 0.0
+		}
+	}
+	return maFrom(tempBuffer, 1, inTimePeriod, inMAType.Spec())
+}
+
+// ForceIndex - Elder's Force Index: (close - prevClose) * volume, smoothed with the given moving average
+// real = ForceIndex(close, volume, timeperiod=13, matype=EMA)
+func ForceIndex(inClose []float64, inVolume []float64, inTimePeriod int, inMAType MaType) []float64 {
+
+	tempBuffer := make([]float64, len(inClose))
+	for today := 1; today < len(inClose); today++ {
+		tempBuffer[today] = (inClose[today] - inClose[today-1]) * inVolume[today]
+	}
+	return maFrom(tempBuffer, 1, inTimePeriod, inMAType.Spec())
+}
+
+// Klinger - Klinger Volume Oscillator
+// kvo, signal = Klinger(high, low, close, volume, fastperiod=34, slowperiod=55, signalperiod=13, matype=EMA)
+func Klinger(inHigh []float64, inLow []float64, inClose []float64, inVolume []float64, inFastPeriod int, inSlowPeriod int, inSignalPeriod int, inMAType MaType) ([]float64, []float64) {
+
+	if inSlowPeriod < inFastPeriod {
+		inSlowPeriod, inFastPeriod = inFastPeriod, inSlowPeriod
+	}
+
+	volumeForce := make([]float64, len(inClose))
+	prevTrend := 0.0
+	prevDM := 0.0
+	cm := 0.0
+	for today := 1; today < len(inClose); today++ {
+		trend := -1.0
+		if inHigh[today]+inLow[today]+inClose[today] > inHigh[today-1]+inLow[today-1]+inClose[today-1] {
+			trend = 1.0
+		}
+		dm := inHigh[today] - inLow[today]
+		if trend == prevTrend {
+			cm += dm
+		} else {
+			cm = prevDM + dm
+		}
+		if cm > 0.00000000000001 {
+			volumeForce[today] = inVolume[today] * math.Abs(2.0*((dm/cm)-1.0)) * trend * 100.0
+		} else {
+			volumeForce[today] = 0.0
+		}
+		prevTrend = trend
+		prevDM = dm
+	}
+
+	fastMA := maFrom(volumeForce, 1, inFastPeriod, inMAType.Spec())
+	outKVO := maFrom(volumeForce, 1, inSlowPeriod, inMAType.Spec())
+	startIdx := 1 + maLookback(inSlowPeriod, inMAType.Spec())
+	for today := startIdx; today < len(inClose); today++ {
+		outKVO[today] = fastMA[today] - outKVO[today]
+	}
+	outSignal := maFrom(outKVO, startIdx, inSignalPeriod, inMAType.Spec())
+
+	return outKVO, outSignal
+}
+
+// Nvi - Negative Volume Index, starting at 1000 and following the close on bars where volume decreased
+func Nvi(inClose []float64, inVolume []float64) []float64 {
+
+	outReal := make([]float64, len(inClose))
+
+	if len(inClose) == 0 {
+		return outReal
+	}
+	outReal[0] = 1000.0
+	for today := 1; today < len(inClose); today++ {
+		outReal[today] = outReal[today-1]
+		if inVolume[today] < inVolume[today-1] && inClose[today-1] != 0.0 {
+			outReal[today] *= inClose[today] / inClose[today-1]
+		}
+	}
+	return outReal
+}
+
+// Pvi - Positive Volume Index, starting at 1000 and following the close on bars where volume increased
+func Pvi(inClose []float64, inVolume []float64) []float64 {
+
+	outReal := make([]float64, len(inClose))
+
+	if len(inClose) == 0 {
+		return outReal
+	}
+	outReal[0] = 1000.0
+	for today := 1; today < len(inClose); today++ {
+		outReal[today] = outReal[today-1]
+		if inVolume[today] > inVolume[today-1] && inClose[today-1] != 0.0 {
+			outReal[today] *= inClose[today] / inClose[today-1]
+		}
+	}
+	return outReal
+}
+
+// Pvt - Price Volume Trend (Volume Price Trend): cumulative volume * (close-prevClose)/prevClose
+func Pvt(inClose []float64, inVolume []float64) []float64 {
+
+	outReal := make([]float64, len(inClose))
+
+	pvt := 0.0
+	for today := 1; today < len(inClose); today++ {
+		tempReal := inClose[today-1]
+		if tempReal != 0.0 {
+			pvt += inVolume[today] * (inClose[today] - tempReal) / tempReal
+		}
+		outReal[today] = pvt
+	}
+	return outReal
+}
+
+// VolumeOsc - Volume Oscillator: percentage difference between a fast and a slow moving average of volume
+// real = VolumeOsc(volume, fastperiod=5, slowperiod=10, matype=EMA)
+func VolumeOsc(inVolume []float64, inFastPeriod int, inSlowPeriod int, inMAType MaType) []float64 {
+
+	if inSlowPeriod < inFastPeriod {
+		inSlowPeriod, inFastPeriod = inFastPeriod, inSlowPeriod
+	}
+	tempBuffer := Ma(inVolume, inFastPeriod, inMAType)
+	outReal := Ma(inVolume, inSlowPeriod, inMAType)
+
+	for i := maLookback(inSlowPeriod, inMAType.Spec()); i < len(inVolume); i++ {
+		tempReal := outReal[i]
+		if !(((-(0.00000000000001)) < tempReal) && (tempReal < (0.00000000000001))) {
+			outReal[i] = ((tempBuffer[i] - tempReal) / tempReal) * 100.0
+		} else {
+			outReal[i] = 0.0
+		}
+	}
+	return outReal
+}
+
+// SessionFunc - reports whether the bar stamped cur opens a new session after the bar stamped prev
+type SessionFunc func(prev time.Time, cur time.Time) bool
+
+// DailySession - sessions starting every day at inStart after midnight in loc,
+// e.g. DailySession(chicago, 17*time.Hour) for futures opening at 17:00
+func DailySession(loc *time.Location, inStart time.Duration) SessionFunc {
+	return func(prev time.Time, cur time.Time) bool {
+		py, pm, pd := prev.In(loc).Add(-inStart).Date()
+		cy, cm, cd := cur.In(loc).Add(-inStart).Date()
+		return py != cy || pm != cm || pd != cd
+	}
+}
+
+// WeeklySession - sessions starting every Monday at midnight in loc
+func WeeklySession(loc *time.Location) SessionFunc {
+	return func(prev time.Time, cur time.Time) bool {
+		py, pw := prev.In(loc).ISOWeek()
+		cy, cw := cur.In(loc).ISOWeek()
+		return py != cy || pw != cw
+	}
+}
+
+// MonthlySession - sessions starting on the first day of every month at midnight in loc
+func MonthlySession(loc *time.Location) SessionFunc {
+	return func(prev time.Time, cur time.Time) bool {
+		py, pm, _ := prev.In(loc).Date()
+		cy, cm, _ := cur.In(loc).Date()
+		return py != cy || pm != cm
+	}
+}
+
+// Vwap - Volume Weighted Average Price of the typical price (h+l+c)/3, reset at every session
+// A nil inSession never resets, giving the cumulative Vwap from the first bar.
+func Vwap(inHigh []float64, inLow []float64, inClose []float64, inVolume []float64, inTime []time.Time, inSession SessionFunc) []float64 {
+
+	_, outReal, _ := VwapBands(TypPrice(inHigh, inLow, inClose), inVolume, inTime, inSession, 0.0, 0.0)
+	return outReal
+}
+
+// VwapBands - session Vwap of any price series with volume weighted standard deviation bands
+// upperband, vwap, lowerband = VwapBands(TypPrice(high, low, close), volume, time, session, nbdevup=1, nbdevdn=1)
+func VwapBands(inReal []float64, inVolume []float64, inTime []time.Time, inSession SessionFunc, inNbDevUp float64, inNbDevDn float64) ([]float64, []float64, []float64) {
+
+	newSession := func(today int) bool {
+		return inSession != nil && inSession(inTime[today-1], inTime[today])
+	}
+	return vwapBands(inReal, inVolume, 0, newSession, inNbDevUp, inNbDevDn)
+}
+
+// AnchoredVwap - Vwap of any price series accumulated from the bar at inAnchorIdx, 0 before it
+// e.g. AnchoredVwap(TypPrice(high, low, close), volume, earningsIdx)
+func AnchoredVwap(inReal []float64, inVolume []float64, inAnchorIdx int) []float64 {
+
+	_, outReal, _ := AnchoredVwapBands(inReal, inVolume, inAnchorIdx, 0.0, 0.0)
+	return outReal
+}
+
+// AnchoredVwapBands - anchored Vwap with volume weighted standard deviation bands
+// upperband, vwap, lowerband = AnchoredVwapBands(price, volume, anchoridx, nbdevup=1, nbdevdn=1)
+func AnchoredVwapBands(inReal []float64, inVolume []float64, inAnchorIdx int, inNbDevUp float64, inNbDevDn float64) ([]float64, []float64, []float64) {
+
+	if inAnchorIdx < 0 {
+		inAnchorIdx = 0
+	}
+	return vwapBands(inReal, inVolume, inAnchorIdx, func(int) bool { return false }, inNbDevUp, inNbDevDn)
+}
+
+// vwapBands - Vwap and deviation bands accumulated from startIdx, restarting on every bar where reset is true
+func vwapBands(inReal []float64, inVolume []float64, startIdx int, reset func(today int) bool, inNbDevUp float64, inNbDevDn float64) ([]float64, []float64, []float64) {
+
+	outRealUpperBand := make([]float64, len(inReal))
+	outRealMiddleBand := make([]float64, len(inReal))
+	outRealLowerBand := make([]float64, len(inReal))
+
+	sumV, sumPV, sumP2V := 0.0, 0.0, 0.0
+	for today := startIdx; today < len(inReal); today++ {
+		if today > startIdx && reset(today) {
+			sumV, sumPV, sumP2V = 0.0, 0.0, 0.0
+		}
+		tempReal := inReal[today]
+		sumV += inVolume[today]
+		sumPV += tempReal * inVolume[today]
+		sumP2V += tempReal * tempReal * inVolume[today]
+		if sumV < 0.00000000000001 {
+			// no volume traded yet in this session, the price itself is the best estimate
+			outRealUpperBand[today] = tempReal
+			outRealMiddleBand[today] = tempReal
+			outRealLowerBand[today] = tempReal
+			continue
+		}
+		vwap := sumPV / sumV
+		deviation := 0.0
+		variance := (sumP2V / sumV) - (vwap * vwap)
+		if !(variance < 0.00000000000001) {
+			deviation = math.Sqrt(variance)
+		}
+		outRealMiddleBand[today] = vwap
+		outRealUpperBand[today] = vwap + (deviation * inNbDevUp)
+		outRealLowerBand[today] = vwap - (deviation * inNbDevDn)
+	}
+	return outRealUpperBand, outRealMiddleBand, outRealLowerBand
+}
+
+/* Market Profile */
+
+// ProfileDistribution - how a bar's volume is spread over the price levels of its high-low range
+type ProfileDistribution int
+
+// Volume distribution models
+const (
+	// ProfileUniform spreads the volume evenly over every level between low and high
+	ProfileUniform ProfileDistribution = iota
+	// ProfileTriangular weights the levels linearly, peaking at the typical price (h+l+c)/3
+	ProfileTriangular
+	// ProfileClose puts all the volume at the level of the close
+	ProfileClose
+	// ProfileTypical puts all the volume at the level of the typical price (h+l+c)/3
+	ProfileTypical
+)
+
+// Profile - price histogram of a range of bars, by volume or by TPO count.
+// Price levels are multiples of the bin size, in ascending order.
+// The bin size is in price units, typically the tick size or a multiple of it. Every bar touches one level per
+// bin of its high-low range, so bins far below the typical bar range cost time and memory accordingly.
+// A bin size <= 0 gives an empty Profile, and zeros from the functions returning per-bar series.
+type Profile struct {
+	StartIdx int // first bar of the profile
+	EndIdx   int // last bar of the profile, inclusive
+	Prices   []float64
+	Values   []float64 // volume (or number of TPOs) per price level
+	Letters  []string  // TPO letters per price level, nil for volume profiles
+	Poc      float64   // point of control: price level with the highest value
+	Vah      float64   // value area high
+	Val      float64   // value area low
+}
+
+// VolumeProfile - price by volume histogram of all the given bars
+// profile = VolumeProfile(high, low, close, volume, binsize=0.25, valuearea=0.7, distribution=ProfileUniform)
+// Slice the inputs to profile a range of bars, e.g. VolumeProfile(high[a:b], low[a:b], close[a:b], volume[a:b], ...).
+func VolumeProfile(inHigh []float64, inLow []float64, inClose []float64, inVolume []float64, inBinSize float64, inValueArea float64, inDistribution ProfileDistribution) Profile {
+
+	if !(inBinSize > 0.0) {
+		return Profile{}
+	}
+	bins := newProfileBins()
+	for today := 0; today < len(inClose); today++ {
+		bins.add(inHigh[today], inLow[today], inClose[today], inVolume[today], inBinSize, inDistribution, 1)
+	}
+	profile := bins.profile(inBinSize, inValueArea)
+	profile.EndIdx = len(inClose) - 1
+	return profile
+}
+
+// RollingVolumeProfile - point of control and value area of the volume profile over the last timeperiod bars
+// poc, vah, val = RollingVolumeProfile(high, low, close, volume, timeperiod=20, binsize=0.25, valuearea=0.7, distribution=ProfileUniform)
+func RollingVolumeProfile(inHigh []float64, inLow []float64, inClose []float64, inVolume []float64, inTimePeriod int, inBinSize float64, inValueArea float64, inDistribution ProfileDistribution) ([]float64, []float64, []float64) {
+
+	outPoc := make([]float64, len(inClose))
+	outVah := make([]float64, len(inClose))
+	outVal := make([]float64, len(inClose))
+
+	if inTimePeriod < 1 || !(inBinSize > 0.0) {
+		return outPoc, outVah, outVal
+	}
+
+	bins := newProfileBins()
+	trailingIdx := 0
+	for today := 0; today < len(inClose); today++ {
+		bins.add(inHigh[today], inLow[today], inClose[today], inVolume[today], inBinSize, inDistribution, 1)
+		if today >= inTimePeriod {
+			bins.add(inHigh[trailingIdx], inLow[trailingIdx], inClose[trailingIdx], inVolume[trailingIdx], inBinSize, inDistribution, -1)
+			trailingIdx++
+		}
+		if today >= inTimePeriod-1 {
+			profile := bins.profile(inBinSize, inValueArea)
+			outPoc[today], outVah[today], outVal[today] = profile.Poc, profile.Vah, profile.Val
+		}
+	}
+	return outPoc, outVah, outVal
+}
+
+// SessionVolumeProfiles - one complete volume profile per session
+func SessionVolumeProfiles(inHigh []float64, inLow []float64, inClose []float64, inVolume []float64, inTime []time.Time, inSession SessionFunc, inBinSize float64, inValueArea float64, inDistribution ProfileDistribution) []Profile {
+
+	var outProfiles []Profile
+	if !(inBinSize > 0.0) {
+		return outProfiles
+	}
+	starts := sessionStarts(inTime, inSession)
+	for i, startIdx := range starts {
+		endIdx := len(inClose)
+		if i+1 < len(starts) {
+			endIdx = starts[i+1]
+		}
+		profile := VolumeProfile(inHigh[startIdx:endIdx], inLow[startIdx:endIdx], inClose[startIdx:endIdx], inVolume[startIdx:endIdx], inBinSize, inValueArea, inDistribution)
+		profile.StartIdx = startIdx
+		profile.EndIdx = endIdx - 1
+		outProfiles = append(outProfiles, profile)
+	}
+	return outProfiles
+}
+
+// DevelopingVolumeProfile - point of control and value area of the session volume profile as of every bar
+// poc, vah, val = DevelopingVolumeProfile(high, low, close, volume, time, session, binsize=0.25, valuearea=0.7, distribution=ProfileUniform)
+func DevelopingVolumeProfile(inHigh []float64, inLow []float64, inClose []float64, inVolume []float64, inTime []time.Time, inSession SessionFunc, inBinSize float64, inValueArea float64, inDistribution ProfileDistribution) ([]float64, []float64, []float64) {
+
+	outPoc := make([]float64, len(inClose))
+	outVah := make([]float64, len(inClose))
+	outVal := make([]float64, len(inClose))
+
+	if !(inBinSize > 0.0) {
+		return outPoc, outVah, outVal
+	}
+
+	bins := newProfileBins()
+	for today := 0; today < len(inClose); today++ {
+		if today > 0 && inSession != nil && inSession(inTime[today-1], inTime[today]) {
+			bins = newProfileBins()
+		}
+		bins.add(inHigh[today], inLow[today], inClose[today], inVolume[today], inBinSize, inDistribution, 1)
+		profile := bins.profile(inBinSize, inValueArea)
+		outPoc[today], outVah[today], outVal[today] = profile.Poc, profile.Vah, profile.Val
+	}
+	return outPoc, outVah, outVal
+}
+
+// TpoProfiles - one TPO (market profile) per session
+// profiles = TpoProfiles(high, low, time, session, bracket=30*time.Minute, binsize=0.25, valuearea=0.7)
+//
+// Each bracket of the session gets a letter (A-Z, then a-z) that is printed at every price level
+// traded during the bracket; Values holds the number of letters per level.
+func TpoProfiles(inHigh []float64, inLow []float64, inTime []time.Time, inSession SessionFunc, inBracket time.Duration, inBinSize float64, inValueArea float64) []Profile {
+
+	const tpoLetters = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+	var outProfiles []Profile
+	if !(inBinSize > 0.0) {
+		return outProfiles
+	}
+	starts := sessionStarts(inTime, inSession)
+	for i, startIdx := range starts {
+		endIdx := len(inHigh)
+		if i+1 < len(starts) {
+			endIdx = starts[i+1]
+		}
+		bins := newProfileBins()
+		letters := map[int][]byte{}
+		for today := startIdx; today < endIdx; today++ {
+			bracket := 0
+			if inBracket > 0 {
+				bracket = int(inTime[today].Sub(inTime[startIdx]) / inBracket)
+			}
+			letter := tpoLetters[bracket%len(tpoLetters)]
+			lowLevel, highLevel := profileLevels(inHigh[today], inLow[today], inBinSize)
+			for level := lowLevel; level <= highLevel; level++ {
+				levelLetters := letters[level]
+				if len(levelLetters) > 0 && levelLetters[len(levelLetters)-1] == letter {
+					continue
+				}
+				letters[level] = append(levelLetters, letter)
+				bins.addLevel(level, 1.0, 1)
+			}
+		}
+		profile := bins.profile(inBinSize, inValueArea)
+		profile.StartIdx = startIdx
+		profile.EndIdx = endIdx - 1
+		profile.Letters = make([]string, len(profile.Prices))
+		for j, price := range profile.Prices {
+			profile.Letters[j] = string(letters[int(math.Round(price/inBinSize))])
+		}
+		outProfiles = append(outProfiles, profile)
+	}
+	return outProfiles
+}
+
+// sessionStarts - index of the first bar of every session
+func sessionStarts(inTime []time.Time, inSession SessionFunc) []int {
+
+	if len(inTime) == 0 {
+		return nil
+	}
+	starts := []int{0}
+	for today := 1; today < len(inTime); today++ {
+		if inSession != nil && inSession(inTime[today-1], inTime[today]) {
+			starts = append(starts, today)
+		}
+	}
+	return starts
+}
+
+// profileLevels - lowest and highest price level, in bin units, within the bar's range
+func profileLevels(high float64, low float64, binSize float64) (int, int) {
+
+	lowLevel := int(math.Ceil(low/binSize - 0.000001))
+	highLevel := int(math.Floor(high/binSize + 0.000001))
+	if lowLevel > highLevel {
+		// range narrower than a bin: use the nearest level to the midpoint
+		lowLevel = int(math.Round((high + low) / 2.0 / binSize))
+		highLevel = lowLevel
+	}
+	return lowLevel, highLevel
+}
+
+// profileBins - histogram keyed by price level in bin units, with the number of bars adding to every level
+type profileBins struct {
+	values map[int]float64
+	counts map[int]int
+}
+
+// newProfileBins - empty histogram
+func newProfileBins() profileBins {
+	return profileBins{values: map[int]float64{}, counts: map[int]int{}}
+}
+
+// add spreads the volume of a bar over the histogram, a count of -1 removes a previously added bar
+func (p profileBins) add(high float64, low float64, close float64, volume float64, binSize float64, dist ProfileDistribution, count int) {
+
+	volume *= float64(count)
+
+	typical := (high + low + close) / 3.0
+	switch dist {
+	case ProfileClose:
+		p.addLevel(int(math.Round(close/binSize)), volume, count)
+		return
+	case ProfileTypical:
+		p.addLevel(int(math.Round(typical/binSize)), volume, count)
+		return
+	}
+
+	lowLevel, highLevel := profileLevels(high, low, binSize)
+	if dist == ProfileTriangular {
+		span := math.Max(high-typical, typical-low) + binSize
+		totalWeight := 0.0
+		for level := lowLevel; level <= highLevel; level++ {
+			totalWeight += 1.0 - math.Abs(float64(level)*binSize-typical)/span
+		}
+		for level := lowLevel; level <= highLevel; level++ {
+			p.addLevel(level, volume*(1.0-math.Abs(float64(level)*binSize-typical)/span)/totalWeight, count)
+		}
+		return
+	}
+	share := volume / float64(highLevel-lowLevel+1)
+	for level := lowLevel; level <= highLevel; level++ {
+		p.addLevel(level, share, count)
+	}
+}
+
+// addLevel adds to a level, a count of -1 removes a contribution and drops the level once it is empty
+func (p profileBins) addLevel(level int, volume float64, count int) {
+	p.counts[level] += count
+	p.values[level] += volume
+	if p.counts[level] <= 0 {
+		delete(p.values, level)
+		delete(p.counts, level)
+	}
+}
+
+// profile - sorted histogram with point of control and value area
+func (p profileBins) profile(binSize float64, valueArea float64) Profile {
+
+	profile := Profile{}
+	if len(p.values) == 0 {
+		return profile
+	}
+	levels := make([]int, 0, len(p.values))
+	for level := range p.values {
+		levels = append(levels, level)
+	}
+	sort.Ints(levels)
+
+	profile.Prices = make([]float64, len(levels))
+	profile.Values = make([]float64, len(levels))
+	total := 0.0
+	pocIdx := 0
+	middle := float64(len(levels)-1) / 2.0
+	for i, level := range levels {
+		profile.Prices[i] = float64(level) * binSize
+		profile.Values[i] = p.values[level]
+		total += p.values[level]
+		if profile.Values[i] > profile.Values[pocIdx] ||
+			(profile.Values[i] == profile.Values[pocIdx] && math.Abs(float64(i)-middle) < math.Abs(float64(pocIdx)-middle)) {
+			pocIdx = i
+		}
+	}
+
+	// grow the value area from the point of control towards the heavier neighbour
+	lowIdx, highIdx := pocIdx, pocIdx
+	accumulated := profile.Values[pocIdx]
+	for accumulated < total*valueArea && (lowIdx > 0 || highIdx < len(levels)-1) {
+		up, down := -1.0, -1.0
+		if highIdx < len(levels)-1 {
+			up = profile.Values[highIdx+1]
+		}
+		if lowIdx > 0 {
+			down = profile.Values[lowIdx-1]
+		}
+		if up >= down {
+			highIdx++
+			accumulated += up
+		} else {
+			lowIdx--
+			accumulated += down
+		}
+	}
+	profile.Poc = profile.Prices[pocIdx]
+	profile.Vah = profile.Prices[highIdx]
+	profile.Val = profile.Prices[lowIdx]
+	return profile
+}
+
 /* Volatility Indicators */
 
 // Atr - Average True Range
@@ -3625,23 +5616,596 @@ func TRange(inHigh []float64, inLow []float64, inClose []float64) []float64 {
 	return outReal
 }
 
-/* Price Transform */
-
-// AvgPrice - Average Price (o+h+l+c)/4
-func AvgPrice(inOpen []float64, inHigh []float64, inLow []float64, inClose []float64) []float64 {
+// Supertrend - Supertrend
+// supertrend, direction, flip = Supertrend(high, low, close, timeperiod=10, multiplier=3)
+//
+// Bands are placed at (high+low)/2 +/- multiplier * Atr and only ratchet in the direction of the trend.
+// Direction is 1.0 in an uptrend (supertrend is the lower band) and -1.0 in a downtrend, starting in a downtrend.
+// Flip is 1.0 on the bar the trend turns up, -1.0 on the bar it turns down and 0.0 otherwise.
+func Supertrend(inHigh []float64, inLow []float64, inClose []float64, inTimePeriod int, inMultiplier float64) ([]float64, []float64, []float64) {
 
 	outReal := make([]float64, len(inClose))
-	outIdx := 0
-	startIdx := 0
+	outDirection := make([]float64, len(inClose))
+	outFlip := make([]float64, len(inClose))
 
-	for i := startIdx; i < len(inClose); i++ {
-		outReal[outIdx] = (inHigh[i] + inLow[i] + inClose[i] + inOpen[i]) / 4
-		outIdx++
+	stream := NewSupertrendStream(inTimePeriod, inMultiplier)
+	for today := 0; today < len(inClose); today++ {
+		outReal[today], outDirection[today], outFlip[today] = stream.Update(inHigh[today], inLow[today], inClose[today])
 	}
-	return outReal
+	return outReal, outDirection, outFlip
 }
 
-// MedPrice - Median Price (h+l)/2
+// SupertrendStream - incremental Supertrend, fed one bar at a time
+type SupertrendStream struct {
+	atr        atrStream
+	multiplier float64
+	finalUpper float64
+	finalLower float64
+	direction  float64
+	prevClose  float64
+}
+
+// NewSupertrendStream - creates a SupertrendStream, see Supertrend for the parameters
+func NewSupertrendStream(inTimePeriod int, inMultiplier float64) *SupertrendStream {
+	return &SupertrendStream{atr: atrStream{period: inTimePeriod}, multiplier: inMultiplier}
+}
+
+// Update adds a bar and returns supertrend, direction and flip for it, all 0.0 inside the Atr lookback
+func (s *SupertrendStream) Update(inHigh float64, inLow float64, inClose float64) (float64, float64, float64) {
+
+	atr, ok := s.atr.update(inHigh, inLow, inClose)
+	if !ok {
+		s.prevClose = inClose
+		return 0.0, 0.0, 0.0
+	}
+
+	hl2 := (inHigh + inLow) / 2.0
+	basicUpper := hl2 + (s.multiplier * atr)
+	basicLower := hl2 - (s.multiplier * atr)
+
+	if s.direction == 0.0 {
+		s.finalUpper = basicUpper
+		s.finalLower = basicLower
+		s.direction = -1.0
+	} else {
+		if basicUpper < s.finalUpper || s.prevClose > s.finalUpper {
+			s.finalUpper = basicUpper
+		}
+		if basicLower > s.finalLower || s.prevClose < s.finalLower {
+			s.finalLower = basicLower
+		}
+	}
+	s.prevClose = inClose
+
+	flip := 0.0
+	if s.direction < 0.0 && inClose > s.finalUpper {
+		s.direction = 1.0
+		flip = 1.0
+	} else if s.direction > 0.0 && inClose < s.finalLower {
+		s.direction = -1.0
+		flip = -1.0
+	}
+	if s.direction > 0.0 {
+		return s.finalLower, s.direction, flip
+	}
+	return s.finalUpper, s.direction, flip
+}
+
+// ChandelierExit - Chandelier Exit
+// longstop, shortstop, direction = ChandelierExit(high, low, close, timeperiod=22, multiplier=3)
+//
+// The long stop hangs multiplier * Atr below the highest high of the period and never moves down while the
+// previous close holds above it; the short stop mirrors it above the lowest low. Direction is 1.0 when long
+// (close above the previous short stop), -1.0 when short (close below the previous long stop), starting long.
+func ChandelierExit(inHigh []float64, inLow []float64, inClose []float64, inTimePeriod int, inMultiplier float64) ([]float64, []float64, []float64) {
+
+	outLongStop := make([]float64, len(inClose))
+	outShortStop := make([]float64, len(inClose))
+	outDirection := make([]float64, len(inClose))
+
+	stream := NewChandelierExitStream(inTimePeriod, inMultiplier)
+	for today := 0; today < len(inClose); today++ {
+		outLongStop[today], outShortStop[today], outDirection[today] = stream.Update(inHigh[today], inLow[today], inClose[today])
+	}
+	return outLongStop, outShortStop, outDirection
+}
+
+// ChandelierExitStream - incremental Chandelier Exit, fed one bar at a time
+type ChandelierExitStream struct {
+	atr        atrStream
+	multiplier float64
+	highs      []float64
+	lows       []float64
+	count      int
+	longStop   float64
+	shortStop  float64
+	direction  float64
+	prevClose  float64
+}
+
+// NewChandelierExitStream - creates a ChandelierExitStream, see ChandelierExit for the parameters
+// A timeperiod below 1 gives a stream that never fills and returns zeros.
+func NewChandelierExitStream(inTimePeriod int, inMultiplier float64) *ChandelierExitStream {
+	if inTimePeriod < 1 {
+		return &ChandelierExitStream{}
+	}
+	return &ChandelierExitStream{
+		atr:        atrStream{period: inTimePeriod},
+		multiplier: inMultiplier,
+		highs:      make([]float64, inTimePeriod),
+		lows:       make([]float64, inTimePeriod),
+	}
+}
+
+// Update adds a bar and returns long stop, short stop and direction for it, all 0.0 inside the Atr lookback
+func (s *ChandelierExitStream) Update(inHigh float64, inLow float64, inClose float64) (float64, float64, float64) {
+
+	if len(s.highs) == 0 {
+		return 0.0, 0.0, 0.0
+	}
+	s.highs[s.count%len(s.highs)] = inHigh
+	s.lows[s.count%len(s.lows)] = inLow
+	s.count++
+
+	atr, ok := s.atr.update(inHigh, inLow, inClose)
+	if !ok {
+		s.prevClose = inClose
+		return 0.0, 0.0, 0.0
+	}
+
+	highest := s.highs[0]
+	lowest := s.lows[0]
+	for i := 1; i < len(s.highs); i++ {
+		if s.highs[i] > highest {
+			highest = s.highs[i]
+		}
+		if s.lows[i] < lowest {
+			lowest = s.lows[i]
+		}
+	}
+	longStop := highest - (s.multiplier * atr)
+	shortStop := lowest + (s.multiplier * atr)
+
+	if s.direction == 0.0 {
+		s.direction = 1.0
+	} else {
+		prevLongStop, prevShortStop := s.longStop, s.shortStop
+		if s.prevClose > prevLongStop && prevLongStop > longStop {
+			longStop = prevLongStop
+		}
+		if s.prevClose < prevShortStop && prevShortStop < shortStop {
+			shortStop = prevShortStop
+		}
+		if inClose > prevShortStop {
+			s.direction = 1.0
+		} else if inClose < prevLongStop {
+			s.direction = -1.0
+		}
+	}
+	s.longStop = longStop
+	s.shortStop = shortStop
+	s.prevClose = inClose
+	return s.longStop, s.shortStop, s.direction
+}
+
+// AtrTrailingStop - Atr Trailing Stop
+// stop, direction = AtrTrailingStop(high, low, close, timeperiod=14, multiplier=3)
+//
+// The stop trails multiplier * Atr behind the close, only ratchets towards the price and switches side
+// when the close crosses it. Direction is 1.0 when the stop is below the price (long), -1.0 when above.
+func AtrTrailingStop(inHigh []float64, inLow []float64, inClose []float64, inTimePeriod int, inMultiplier float64) ([]float64, []float64) {
+
+	outReal := make([]float64, len(inClose))
+	outDirection := make([]float64, len(inClose))
+
+	stream := NewAtrTrailingStopStream(inTimePeriod, inMultiplier)
+	for today := 0; today < len(inClose); today++ {
+		outReal[today], outDirection[today] = stream.Update(inHigh[today], inLow[today], inClose[today])
+	}
+	return outReal, outDirection
+}
+
+// AtrTrailingStopStream - incremental Atr Trailing Stop, fed one bar at a time
+type AtrTrailingStopStream struct {
+	atr        atrStream
+	multiplier float64
+	trail      trailingStop
+}
+
+// NewAtrTrailingStopStream - creates an AtrTrailingStopStream, see AtrTrailingStop for the parameters
+func NewAtrTrailingStopStream(inTimePeriod int, inMultiplier float64) *AtrTrailingStopStream {
+	return &AtrTrailingStopStream{atr: atrStream{period: inTimePeriod}, multiplier: inMultiplier}
+}
+
+// Update adds a bar and returns stop and direction for it, both 0.0 inside the Atr lookback
+func (s *AtrTrailingStopStream) Update(inHigh float64, inLow float64, inClose float64) (float64, float64) {
+
+	atr, ok := s.atr.update(inHigh, inLow, inClose)
+	if !ok {
+		return 0.0, 0.0
+	}
+	return s.trail.update(inClose, s.multiplier*atr)
+}
+
+// KaseDevStop - Kase Dev Stop
+// stop, direction = KaseDevStop(high, low, close, timeperiod=20, nbdev=2.2)
+//
+// The stop distance is the average two-bar true range plus nbdev standard deviations of it;
+// Kase's warning line and three dev stops use nbdev 0, 1, 2.2 and 3.6. The stop ratchets like
+// AtrTrailingStop. Direction is 1.0 when the stop is below the price (long), -1.0 when above.
+func KaseDevStop(inHigh []float64, inLow []float64, inClose []float64, inTimePeriod int, inNbDev float64) ([]float64, []float64) {
+
+	outReal := make([]float64, len(inClose))
+	outDirection := make([]float64, len(inClose))
+
+	stream := NewKaseDevStopStream(inTimePeriod, inNbDev)
+	for today := 0; today < len(inClose); today++ {
+		outReal[today], outDirection[today] = stream.Update(inHigh[today], inLow[today], inClose[today])
+	}
+	return outReal, outDirection
+}
+
+// KaseDevStopStream - incremental Kase Dev Stop, fed one bar at a time
+type KaseDevStopStream struct {
+	nbDev       float64
+	ranges      []float64
+	count       int
+	periodTotal float64
+	periodSqr   float64
+	highs       [2]float64
+	lows        [2]float64
+	closes      [2]float64
+	trail       trailingStop
+}
+
+// NewKaseDevStopStream - creates a KaseDevStopStream, see KaseDevStop for the parameters
+// A timeperiod below 1 gives a stream that never fills and returns zeros.
+func NewKaseDevStopStream(inTimePeriod int, inNbDev float64) *KaseDevStopStream {
+	if inTimePeriod < 1 {
+		return &KaseDevStopStream{}
+	}
+	return &KaseDevStopStream{nbDev: inNbDev, ranges: make([]float64, inTimePeriod)}
+}
+
+// Update adds a bar and returns stop and direction for it, both 0.0 for the first timeperiod+1 bars
+func (s *KaseDevStopStream) Update(inHigh float64, inLow float64, inClose float64) (float64, float64) {
+
+	bar := s.count
+	s.count++
+	if bar < 2 || len(s.ranges) == 0 {
+		s.highs[bar%2], s.lows[bar%2], s.closes[bar%2] = inHigh, inLow, inClose
+		return 0.0, 0.0
+	}
+
+	// two-bar true range: range of the current and previous bar, extended to the close two bars ago
+	highest := math.Max(inHigh, s.highs[(bar-1)%2])
+	lowest := math.Min(inLow, s.lows[(bar-1)%2])
+	tempReal := s.closes[bar%2]
+	highest = math.Max(highest, tempReal)
+	lowest = math.Min(lowest, tempReal)
+	trueRange := highest - lowest
+	s.highs[bar%2], s.lows[bar%2], s.closes[bar%2] = inHigh, inLow, inClose
+
+	period := len(s.ranges)
+	slot := (bar - 2) % period
+	if bar-2 >= period {
+		s.periodTotal -= s.ranges[slot]
+		s.periodSqr -= s.ranges[slot] * s.ranges[slot]
+	}
+	s.ranges[slot] = trueRange
+	s.periodTotal += trueRange
+	s.periodSqr += trueRange * trueRange
+	if bar-2 < period-1 {
+		return 0.0, 0.0
+	}
+
+	mean := s.periodTotal / float64(period)
+	variance := (s.periodSqr / float64(period)) - (mean * mean)
+	deviation := 0.0
+	if !(variance < 0.00000000000001) {
+		deviation = math.Sqrt(variance)
+	}
+	return s.trail.update(inClose, mean+(s.nbDev*deviation))
+}
+
+// atrStream - incremental Atr, identical to Atr bar by bar
+type atrStream struct {
+	period    int
+	count     int
+	prevClose float64
+	sum       float64
+	atr       float64
+}
+
+// update adds a bar and returns the Atr, ok is false inside the lookback
+func (a *atrStream) update(inHigh float64, inLow float64, inClose float64) (float64, bool) {
+
+	a.count++
+	if a.count == 1 || a.period < 1 {
+		a.prevClose = inClose
+		return 0.0, false
+	}
+	greatest := inHigh - inLow
+	val2 := math.Abs(a.prevClose - inHigh)
+	if val2 > greatest {
+		greatest = val2
+	}
+	val3 := math.Abs(a.prevClose - inLow)
+	if val3 > greatest {
+		greatest = val3
+	}
+	a.prevClose = inClose
+
+	periodF := float64(a.period)
+	if a.count <= a.period {
+		a.sum += greatest
+		return 0.0, false
+	}
+	if a.count == a.period+1 {
+		a.sum += greatest
+		a.atr = a.sum / periodF
+		return a.atr, true
+	}
+	a.atr *= periodF - 1.0
+	a.atr += greatest
+	a.atr /= periodF
+	return a.atr, true
+}
+
+// trailingStop - stop that trails the close by a distance, ratchets towards the price and flips on a close through it
+type trailingStop struct {
+	stop      float64
+	direction float64
+}
+
+// update moves the stop for a new close and distance and returns stop and direction
+func (t *trailingStop) update(inClose float64, inDistance float64) (float64, float64) {
+
+	if t.direction == 0.0 {
+		t.stop = inClose - inDistance
+		t.direction = 1.0
+	} else if t.direction > 0.0 {
+		if inClose < t.stop {
+			t.stop = inClose + inDistance
+			t.direction = -1.0
+		} else {
+			t.stop = math.Max(t.stop, inClose-inDistance)
+		}
+	} else {
+		if inClose > t.stop {
+			t.stop = inClose - inDistance
+			t.direction = 1.0
+		} else {
+			t.stop = math.Min(t.stop, inClose+inDistance)
+		}
+	}
+	return t.stop, t.direction
+}
+
+// ReturnType - how bar to bar returns are measured
+type ReturnType int
+
+// Kinds of returns
+const (
+	LogReturns ReturnType = iota
+	SimpleReturns
+)
+
+// Returns - bar to bar returns of a price series, 0 for the first bar
+// log: ln(price/prevPrice), simple: (price-prevPrice)/prevPrice
+func Returns(inReal []float64, inReturnType ReturnType) []float64 {
+
+	outReal := make([]float64, len(inReal))
+
+	for today := 1; today < len(inReal); today++ {
+		tempReal := inReal[today-1]
+		if tempReal == 0.0 {
+			continue
+		}
+		if inReturnType == SimpleReturns {
+			outReal[today] = (inReal[today] - tempReal) / tempReal
+		} else {
+			outReal[today] = math.Log(inReal[today] / tempReal)
+		}
+	}
+	return outReal
+}
+
+// HistVol - Close-to-close historical volatility: annualized sample standard deviation of returns
+// real = HistVol(close, timeperiod=20, annualization=252, returntype=LogReturns)
+// annualization is the number of bars per year (252 trading days, 365 calendar days, 252*390 one minute bars...).
+func HistVol(inClose []float64, inTimePeriod int, inAnnualization float64, inReturnType ReturnType) []float64 {
+
+	outReal := make([]float64, len(inClose))
+
+	if inTimePeriod < 2 {
+		return outReal
+	}
+
+	returns := Returns(inClose, inReturnType)
+	inTimePeriodF := float64(inTimePeriod)
+	periodTotal1 := 0.0
+	periodTotal2 := 0.0
+	for today := 1; today < len(inClose); today++ {
+		tempReal := returns[today]
+		periodTotal1 += tempReal
+		periodTotal2 += tempReal * tempReal
+		if today > inTimePeriod {
+			tempReal = returns[today-inTimePeriod]
+			periodTotal1 -= tempReal
+			periodTotal2 -= tempReal * tempReal
+		}
+		if today >= inTimePeriod {
+			variance := (periodTotal2 - (periodTotal1*periodTotal1)/inTimePeriodF) / (inTimePeriodF - 1.0)
+			outReal[today] = annualizedVol(variance, inAnnualization)
+		}
+	}
+	return outReal
+}
+
+// ParkinsonVol - Parkinson high-low historical volatility, annualized
+// real = ParkinsonVol(high, low, timeperiod=20, annualization=252)
+func ParkinsonVol(inHigh []float64, inLow []float64, inTimePeriod int, inAnnualization float64) []float64 {
+
+	terms := make([]float64, len(inHigh))
+	factor := 1.0 / (4.0 * math.Ln2)
+	for today := 0; today < len(inHigh); today++ {
+		tempReal := math.Log(inHigh[today] / inLow[today])
+		terms[today] = factor * tempReal * tempReal
+	}
+	return rollingVol(terms, inTimePeriod, inAnnualization)
+}
+
+// GarmanKlassVol - Garman-Klass open-high-low-close historical volatility, annualized
+// real = GarmanKlassVol(open, high, low, close, timeperiod=20, annualization=252)
+func GarmanKlassVol(inOpen []float64, inHigh []float64, inLow []float64, inClose []float64, inTimePeriod int, inAnnualization float64) []float64 {
+
+	terms := make([]float64, len(inClose))
+	factor := (2.0 * math.Ln2) - 1.0
+	for today := 0; today < len(inClose); today++ {
+		hl := math.Log(inHigh[today] / inLow[today])
+		co := math.Log(inClose[today] / inOpen[today])
+		terms[today] = (0.5 * hl * hl) - (factor * co * co)
+	}
+	return rollingVol(terms, inTimePeriod, inAnnualization)
+}
+
+// RogersSatchellVol - Rogers-Satchell drift independent historical volatility, annualized
+// real = RogersSatchellVol(open, high, low, close, timeperiod=20, annualization=252)
+func RogersSatchellVol(inOpen []float64, inHigh []float64, inLow []float64, inClose []float64, inTimePeriod int, inAnnualization float64) []float64 {
+
+	return rollingVol(rogersSatchellTerms(inOpen, inHigh, inLow, inClose), inTimePeriod, inAnnualization)
+}
+
+// YangZhangVol - Yang-Zhang historical volatility combining overnight, open-to-close and Rogers-Satchell variances, annualized
+// real = YangZhangVol(open, high, low, close, timeperiod=20, annualization=252)
+func YangZhangVol(inOpen []float64, inHigh []float64, inLow []float64, inClose []float64, inTimePeriod int, inAnnualization float64) []float64 {
+
+	outReal := make([]float64, len(inClose))
+
+	if inTimePeriod < 2 {
+		return outReal
+	}
+
+	inTimePeriodF := float64(inTimePeriod)
+	k := 0.34 / (1.34 + (inTimePeriodF+1.0)/(inTimePeriodF-1.0))
+	rsTerms := rogersSatchellTerms(inOpen, inHigh, inLow, inClose)
+	overnight := make([]float64, len(inClose))
+	openClose := make([]float64, len(inClose))
+	for today := 1; today < len(inClose); today++ {
+		overnight[today] = math.Log(inOpen[today] / inClose[today-1])
+		openClose[today] = math.Log(inClose[today] / inOpen[today])
+	}
+
+	sumO, sumO2, sumC, sumC2, sumRS := 0.0, 0.0, 0.0, 0.0, 0.0
+	for today := 1; today < len(inClose); today++ {
+		sumO += overnight[today]
+		sumO2 += overnight[today] * overnight[today]
+		sumC += openClose[today]
+		sumC2 += openClose[today] * openClose[today]
+		sumRS += rsTerms[today]
+		if today > inTimePeriod {
+			trailingIdx := today - inTimePeriod
+			sumO -= overnight[trailingIdx]
+			sumO2 -= overnight[trailingIdx] * overnight[trailingIdx]
+			sumC -= openClose[trailingIdx]
+			sumC2 -= openClose[trailingIdx] * openClose[trailingIdx]
+			sumRS -= rsTerms[trailingIdx]
+		}
+		if today >= inTimePeriod {
+			varO := (sumO2 - (sumO*sumO)/inTimePeriodF) / (inTimePeriodF - 1.0)
+			varC := (sumC2 - (sumC*sumC)/inTimePeriodF) / (inTimePeriodF - 1.0)
+			varRS := sumRS / inTimePeriodF
+			outReal[today] = annualizedVol(varO+(k*varC)+((1.0-k)*varRS), inAnnualization)
+		}
+	}
+	return outReal
+}
+
+// rogersSatchellTerms - ln(h/c)*ln(h/o) + ln(l/c)*ln(l/o) per bar
+func rogersSatchellTerms(inOpen []float64, inHigh []float64, inLow []float64, inClose []float64) []float64 {
+
+	terms := make([]float64, len(inClose))
+	for today := 0; today < len(inClose); today++ {
+		hc := math.Log(inHigh[today] / inClose[today])
+		ho := math.Log(inHigh[today] / inOpen[today])
+		lc := math.Log(inLow[today] / inClose[today])
+		lo := math.Log(inLow[today] / inOpen[today])
+		terms[today] = (hc * ho) + (lc * lo)
+	}
+	return terms
+}
+
+// rollingVol - annualized square root of the rolling mean of per bar variance terms
+func rollingVol(terms []float64, inTimePeriod int, inAnnualization float64) []float64 {
+
+	outReal := make([]float64, len(terms))
+
+	if inTimePeriod < 1 {
+		return outReal
+	}
+	periodTotal := 0.0
+	for today := 0; today < len(terms); today++ {
+		periodTotal += terms[today]
+		if today >= inTimePeriod {
+			periodTotal -= terms[today-inTimePeriod]
+		}
+		if today >= inTimePeriod-1 {
+			outReal[today] = annualizedVol(periodTotal/float64(inTimePeriod), inAnnualization)
+		}
+	}
+	return outReal
+}
+
+// annualizedVol - square root of a per bar variance scaled to a year of bars
+func annualizedVol(variance float64, inAnnualization float64) float64 {
+	if !(variance > 0.0) {
+		return 0.0
+	}
+	return math.Sqrt(variance * inAnnualization)
+}
+
+/* Price Transform */
+
+// AvgDev - Average Deviation: mean absolute deviation from the mean over the period
+func AvgDev(inReal []float64, inTimePeriod int) []float64 {
+
+	outReal := make([]float64, len(inReal))
+
+	if inTimePeriod < 2 {
+		return outReal
+	}
+
+	inTimePeriodF := float64(inTimePeriod)
+	for today := inTimePeriod - 1; today < len(inReal); today++ {
+		todaySum := 0.0
+		for i := 0; i < inTimePeriod; i++ {
+			todaySum += inReal[today-i]
+		}
+		todayDev := 0.0
+		for i := 0; i < inTimePeriod; i++ {
+			todayDev += math.Abs(inReal[today-i] - todaySum/inTimePeriodF)
+		}
+		outReal[today] = todayDev / inTimePeriodF
+	}
+	return outReal
+}
+
+// AvgPrice - Average Price (o+h+l+c)/4
+func AvgPrice(inOpen []float64, inHigh []float64, inLow []float64, inClose []float64) []float64 {
+
+	outReal := make([]float64, len(inClose))
+	outIdx := 0
+	startIdx := 0
+
+	for i := startIdx; i < len(inClose); i++ {
+		outReal[outIdx] = (inHigh[i] + inLow[i] + inClose[i] + inOpen[i]) / 4
+		outIdx++
+	}
+	return outReal
+}
+
+// MedPrice - Median Price (h+l)/2
 func MedPrice(inHigh []float64, inLow []float64) []float64 {
 
 	outReal := make([]float64, len(inHigh))
@@ -3685,6 +6249,129 @@ func WclPrice(inHigh []float64, inLow []float64, inClose []float64) []float64 {
 
 /* Cycle Indicators */
 
+// AutocorrelationPeriodogram - Ehlers Autocorrelation Periodogram dominant cycle period
+// real = AutocorrelationPeriodogram(close, minperiod=10, maxperiod=48, avglength=3)
+//
+// The roofed input is correlated with itself at lags up to maxperiod, each correlation averaged over avglength
+// bars (0: over as many bars as the lag). The power spectrum of those correlations is smoothed, normalised by a
+// slowly decaying peak, and the dominant cycle is the centre of gravity of the periods with at least half the peak power.
+func AutocorrelationPeriodogram(inReal []float64, inMinPeriod int, inMaxPeriod int, inAvgLength int) []float64 {
+
+	outReal := make([]float64, len(inReal))
+
+	if inMinPeriod < 1 || inMaxPeriod < inMinPeriod {
+		return outReal
+	}
+
+	filt := RoofingFilter(inReal, inMaxPeriod, inMinPeriod)
+	length := inAvgLength
+	if length <= 0 {
+		length = inMaxPeriod
+	}
+	corr := make([]float64, inMaxPeriod+1)
+	smoothed := make([]float64, inMaxPeriod+1)
+	maxPower, dominantCycle := 0.0, float64(inMinPeriod)
+	for today := inMaxPeriod + length - 1; today < len(inReal); today++ {
+		for lag := 0; lag <= inMaxPeriod; lag++ {
+			m := inAvgLength
+			if m <= 0 {
+				m = lag
+			}
+			sx, sy, sxx, syy, sxy := 0.0, 0.0, 0.0, 0.0, 0.0
+			for count := 0; count < m; count++ {
+				x := filt[today-count]
+				y := filt[today-lag-count]
+				sx += x
+				sy += y
+				sxx += x * x
+				syy += y * y
+				sxy += x * y
+			}
+			mF := float64(m)
+			corr[lag] = 0.0
+			if tempReal := ((mF * sxx) - (sx * sx)) * ((mF * syy) - (sy * sy)); tempReal > 0.0 {
+				corr[lag] = ((mF * sxy) - (sx * sy)) / math.Sqrt(tempReal)
+			}
+		}
+
+		maxPower *= 0.995
+		for period := inMinPeriod; period <= inMaxPeriod; period++ {
+			cosinePart, sinePart := 0.0, 0.0
+			for n := 3; n <= inMaxPeriod; n++ {
+				tempReal := 2.0 * math.Pi * float64(n) / float64(period)
+				cosinePart += corr[n] * math.Cos(tempReal)
+				sinePart += corr[n] * math.Sin(tempReal)
+			}
+			tempReal := (cosinePart * cosinePart) + (sinePart * sinePart)
+			smoothed[period] = (0.2 * tempReal * tempReal) + (0.8 * smoothed[period])
+			maxPower = math.Max(maxPower, smoothed[period])
+		}
+
+		spx, sp := 0.0, 0.0
+		if maxPower > 0.0 {
+			for period := inMinPeriod; period <= inMaxPeriod; period++ {
+				if power := smoothed[period] / maxPower; power >= 0.5 {
+					spx += float64(period) * power
+					sp += power
+				}
+			}
+		}
+		if sp != 0.0 {
+			dominantCycle = math.Max(float64(inMinPeriod), math.Min(float64(inMaxPeriod), spx/sp))
+		}
+		outReal[today] = dominantCycle
+	}
+	return outReal
+}
+
+// CyberCycle - Ehlers Cyber Cycle and its trigger (the previous value)
+// cycle, trigger = CyberCycle(close, alpha=0.07)
+//
+// A two pole high pass of the [1 2 2 1] smoothed input; the first seven bars are a plain second difference.
+func CyberCycle(inReal []float64, inAlpha float64) ([]float64, []float64) {
+
+	smooth := iirFilter(inReal, []float64{1.0 / 6.0, 2.0 / 6.0, 2.0 / 6.0, 1.0 / 6.0}, []float64{1.0}, inReal, 3)
+	seed := make([]float64, len(inReal))
+	for today := 2; today < 7 && today < len(inReal); today++ {
+		seed[today] = (inReal[today] - (2.0 * inReal[today-1]) + inReal[today-2]) / 4.0
+	}
+	tempReal := (1.0 - (0.5 * inAlpha)) * (1.0 - (0.5 * inAlpha))
+	b := []float64{tempReal, -2.0 * tempReal, tempReal}
+	a := []float64{1.0, -2.0 * (1.0 - inAlpha), (1.0 - inAlpha) * (1.0 - inAlpha)}
+	outCycle := iirFilter(smooth, b, a, seed, 7)
+
+	outTrigger := make([]float64, len(inReal))
+	for today := 1; today < len(inReal); today++ {
+		outTrigger[today] = outCycle[today-1]
+	}
+	return outCycle, outTrigger
+}
+
+// EvenBetterSinewave - Ehlers Even Better Sinewave indicator, normalised to -1..1
+// real = EvenBetterSinewave(close, hpperiod=40, ssperiod=10)
+func EvenBetterSinewave(inReal []float64, inHpPeriod int, inSsPeriod int) []float64 {
+
+	outReal := make([]float64, len(inReal))
+
+	if inHpPeriod < 1 || inSsPeriod < 1 {
+		return outReal
+	}
+
+	tempReal := 2.0 * math.Pi / float64(inHpPeriod)
+	alpha := (1.0 - math.Sin(tempReal)) / math.Cos(tempReal)
+	hp := iirFilter(inReal, []float64{0.5 * (1.0 + alpha), -0.5 * (1.0 + alpha)}, []float64{1.0, -alpha}, nil, 1)
+	b, a := superSmootherCoefficients(float64(inSsPeriod))
+	filt := iirFilter(hp, b, a, hp, 2)
+	for today := 2; today < len(inReal); today++ {
+		wave := (filt[today] + filt[today-1] + filt[today-2]) / 3.0
+		power := ((filt[today] * filt[today]) + (filt[today-1] * filt[today-1]) + (filt[today-2] * filt[today-2])) / 3.0
+		if power > 0.0 {
+			outReal[today] = wave / math.Sqrt(power)
+		}
+	}
+	return outReal
+}
+
 // HtDcPeriod - Hilbert Transform - Dominant Cycle Period (lookback=32)
 func HtDcPeriod(inReal []float64) []float64 {
 
@@ -4641,7 +7328,18 @@ func HtSine(inReal []float64) ([]float64, []float64) {
 // HtTrendMode - Hilbert Transform - Trend vs Cycle Mode (lookback=63)
 func HtTrendMode(inReal []float64) []float64 {
 
+	outReal, _, _, _ := HtTrendModeDetail(inReal)
+	return outReal
+}
+
+// HtTrendModeDetail - Hilbert Transform - Trend vs Cycle Mode with the trendline, sine and leadsine it is decided on (lookback=63)
+// trendmode, trendline, sine, leadsine = HtTrendModeDetail(close)
+func HtTrendModeDetail(inReal []float64) ([]float64, []float64, []float64, []float64) {
+
 	outReal := make([]float64, len(inReal))
+	outTrendline := make([]float64, len(inReal))
+	outSine := make([]float64, len(inReal))
+	outLeadSine := make([]float64, len(inReal))
 	a := 0.0962
 	b := 0.5769
 	detrenderOdd := make([]float64, 3)
@@ -4934,6 +7632,9 @@ func HtTrendMode(inReal []float64) []float64 {
 		}
 		if today >= startIdx {
 			outReal[outIdx] = float64(trend)
+			outTrendline[outIdx] = trendline
+			outSine[outIdx] = sine
+			outLeadSine[outIdx] = leadSine
 			outIdx++
 		}
 		smoothPriceIdx++
@@ -4943,13 +7644,331 @@ func HtTrendMode(inReal []float64) []float64 {
 
 		today++
 	}
-	return outReal
+	return outReal, outTrendline, outSine, outLeadSine
 }
 
-/* Statistic Functions */
-
-// Beta - Beta
-func Beta(inReal0 []float64, inReal1 []float64, inTimePeriod int) []float64 {
+// RoofingFilter - Ehlers Roofing Filter: a two pole high pass removing cycles longer than hpperiod
+// followed by a Super Smoother removing those shorter than ssperiod
+// real = RoofingFilter(close, hpperiod=48, ssperiod=10)
+func RoofingFilter(inReal []float64, inHpPeriod int, inSsPeriod int) []float64 {
+
+	if inHpPeriod < 1 || inSsPeriod < 1 {
+		return make([]float64, len(inReal))
+	}
+	b, a := highPassCoefficients(float64(inHpPeriod))
+	hp := iirFilter(inReal, b, a, nil, 2)
+	b, a = superSmootherCoefficients(float64(inSsPeriod))
+	return iirFilter(hp, b, a, hp, 2)
+}
+
+// iirFilter - direct form IIR filter: a[0]*out[n] = sum b[k]*in[n-k] - sum a[k]*out[n-k] (k >= 1).
+// Bars before startIdx are taken from seed (zeros if nil) and start the recursion; terms before the first bar are dropped.
+func iirFilter(inReal []float64, b []float64, a []float64, seed []float64, startIdx int) []float64 {
+
+	outReal := make([]float64, len(inReal))
+	today := 0
+	for ; today < startIdx && today < len(inReal); today++ {
+		if seed != nil {
+			outReal[today] = seed[today]
+		}
+	}
+	for ; today < len(inReal); today++ {
+		tempReal := 0.0
+		for k := 0; k < len(b) && k <= today; k++ {
+			tempReal += b[k] * inReal[today-k]
+		}
+		for k := 1; k < len(a) && k <= today; k++ {
+			tempReal -= a[k] * outReal[today-k]
+		}
+		outReal[today] = tempReal / a[0]
+	}
+	return outReal
+}
+
+// superSmootherCoefficients - Ehlers Super Smoother biquad: two poles critically placed for the period
+// and a zero at Nyquist
+func superSmootherCoefficients(period float64) ([]float64, []float64) {
+
+	a1 := math.Exp(-math.Sqrt2 * math.Pi / period)
+	c2 := 2.0 * a1 * math.Cos(math.Sqrt2*math.Pi/period)
+	c3 := -a1 * a1
+	c1 := 1.0 - c2 - c3
+	return []float64{c1 / 2.0, c1 / 2.0}, []float64{1.0, -c2, -c3}
+}
+
+// highPassCoefficients - Ehlers two pole high pass biquad for the cutoff period
+func highPassCoefficients(period float64) ([]float64, []float64) {
+
+	tempReal := math.Sqrt2 * math.Pi / period
+	alpha := (math.Cos(tempReal) + math.Sin(tempReal) - 1.0) / math.Cos(tempReal)
+	gain := (1.0 - (alpha / 2.0)) * (1.0 - (alpha / 2.0))
+	return []float64{gain, -2.0 * gain, gain}, []float64{1.0, -2.0 * (1.0 - alpha), (1.0 - alpha) * (1.0 - alpha)}
+}
+
+/* Filter Design */
+
+// Filter - linear filter coefficients: A[0]*out[n] = sum B[k]*in[n-k] - sum A[k]*out[n-k] (k >= 1).
+// An FIR filter has A = [1] (or nil); B is then its kernel, B[0] weighting the current bar.
+type Filter struct {
+	B []float64
+	A []float64
+}
+
+// FirFilter - Applies an FIR kernel to a series: out[n] = sum kernel[k]*in[n-k]
+// As with IirFilter the series is taken to have stood at its first value before the first bar.
+func FirFilter(inReal []float64, inKernel []float64) []float64 {
+	return IirFilter(inReal, inKernel, []float64{1.0})
+}
+
+// IirFilter - Applies IIR coefficients to a series: a[0]*out[n] = sum b[k]*in[n-k] - sum a[k]*out[n-k]
+// The filter starts in the steady state of the first value having been input forever, so there is no lookback:
+// a low pass starts at the first value and a high pass at 0.
+func IirFilter(inReal []float64, inB []float64, inA []float64) []float64 {
+
+	if len(inReal) == 0 {
+		return make([]float64, 0)
+	}
+	if len(inA) == 0 {
+		inA = []float64{1.0}
+	}
+	order := len(inB) - 1
+	if len(inA)-1 > order {
+		order = len(inA) - 1
+	}
+
+	// steady state output for a constant input is the DC gain times that input
+	sumB, sumA := 0.0, 0.0
+	for _, b := range inB {
+		sumB += b
+	}
+	for _, a := range inA {
+		sumA += a
+	}
+	steady := 0.0
+	if sumA != 0.0 {
+		steady = inReal[0] * sumB / sumA
+	}
+	padded := make([]float64, order+len(inReal))
+	seed := make([]float64, order)
+	for i := 0; i < order; i++ {
+		padded[i] = inReal[0]
+		seed[i] = steady
+	}
+	copy(padded[order:], inReal)
+	return iirFilter(padded, inB, inA, seed, order)[order:]
+}
+
+// Apply filters a series with IirFilter
+func (f Filter) Apply(inReal []float64) []float64 {
+	return IirFilter(inReal, f.B, f.A)
+}
+
+// FrequencyResponse returns the gain and the phase (radians, negative for a delay) for cycles of inPeriod bars
+func (f Filter) FrequencyResponse(inPeriod float64) (float64, float64) {
+
+	response := f.response(2.0*math.Pi/inPeriod, 0) / f.denominator(2.0*math.Pi/inPeriod, 0)
+	return cmplx.Abs(response), cmplx.Phase(response)
+}
+
+// GroupDelay returns the delay in bars of cycles of inPeriod bars, -dphase/domega
+func (f Filter) GroupDelay(inPeriod float64) float64 {
+
+	omega := 2.0 * math.Pi / inPeriod
+	numerator := real(f.response(omega, 1) / f.response(omega, 0))
+	denominator := real(f.denominator(omega, 1) / f.denominator(omega, 0))
+	return numerator - denominator
+}
+
+// Lag returns the group delay of the trend (an infinite period), the lag of a smoother in bars;
+// NaN for filters blocking the trend such as high passes.
+func (f Filter) Lag() float64 {
+	return f.GroupDelay(math.Inf(1))
+}
+
+// response - sum k^power * B[k] * exp(-i*omega*k)
+func (f Filter) response(omega float64, power int) complex128 {
+	return polyResponse(f.B, omega, power)
+}
+
+// denominator - sum k^power * A[k] * exp(-i*omega*k), A = [1] when unset
+func (f Filter) denominator(omega float64, power int) complex128 {
+	if len(f.A) == 0 {
+		return polyResponse([]float64{1.0}, omega, power)
+	}
+	return polyResponse(f.A, omega, power)
+}
+
+// SmaFilter - simple moving average as an FIR filter, lag (period-1)/2
+func SmaFilter(inTimePeriod int) Filter {
+
+	kernel := make([]float64, inTimePeriod)
+	for i := range kernel {
+		kernel[i] = 1.0 / float64(inTimePeriod)
+	}
+	return Filter{B: kernel, A: []float64{1.0}}
+}
+
+// WmaFilter - linearly weighted moving average as an FIR filter, lag (period-1)/3
+func WmaFilter(inTimePeriod int) Filter {
+
+	kernel := make([]float64, inTimePeriod)
+	divider := float64(inTimePeriod*(inTimePeriod+1)) / 2.0
+	for i := range kernel {
+		kernel[i] = float64(inTimePeriod-i) / divider
+	}
+	return Filter{B: kernel, A: []float64{1.0}}
+}
+
+// EmaFilter - exponential moving average with k = 2/(period+1) as a one pole IIR filter, lag (period-1)/2
+func EmaFilter(inTimePeriod int) Filter {
+
+	k := 2.0 / float64(inTimePeriod+1)
+	return Filter{B: []float64{k}, A: []float64{1.0, k - 1.0}}
+}
+
+// ButterworthLowPass - Butterworth low pass with the given number of poles, by bilinear transform,
+// passing cycles longer than cutoffperiod bars (-3dB at the cutoff, which must exceed 2 bars)
+func ButterworthLowPass(inCutoffPeriod float64, inPoles int) Filter {
+	return butterworth(inCutoffPeriod, inPoles, false)
+}
+
+// ButterworthHighPass - Butterworth high pass with the given number of poles, by bilinear transform,
+// passing cycles shorter than cutoffperiod bars (-3dB at the cutoff, which must exceed 2 bars)
+func ButterworthHighPass(inCutoffPeriod float64, inPoles int) Filter {
+	return butterworth(inCutoffPeriod, inPoles, true)
+}
+
+// GaussianLowPass - Ehlers Gaussian low pass: poles cascaded EMAs tuned to -3dB at cutoffperiod bars
+func GaussianLowPass(inCutoffPeriod float64, inPoles int) Filter {
+
+	alpha := gaussianAlpha(inCutoffPeriod, inPoles)
+	roots := make([]complex128, inPoles)
+	for i := range roots {
+		roots[i] = complex(1.0-alpha, 0.0)
+	}
+	return Filter{B: []float64{math.Pow(alpha, float64(inPoles))}, A: polyFromRoots(roots)}
+}
+
+// GaussianHighPass - Ehlers Gaussian high pass: the poles of GaussianLowPass with zeros at the trend,
+// unit gain at the shortest (2 bar) cycle
+func GaussianHighPass(inCutoffPeriod float64, inPoles int) Filter {
+
+	alpha := gaussianAlpha(inCutoffPeriod, inPoles)
+	roots := make([]complex128, inPoles)
+	zeros := make([]complex128, inPoles)
+	for i := range roots {
+		roots[i] = complex(1.0-alpha, 0.0)
+		zeros[i] = complex(1.0, 0.0)
+	}
+	b := polyFromRoots(zeros)
+	gain := math.Pow(1.0-(alpha/2.0), float64(inPoles))
+	for i := range b {
+		b[i] *= gain
+	}
+	return Filter{B: b, A: polyFromRoots(roots)}
+}
+
+// gaussianAlpha - EMA factor of each pole of an Ehlers Gaussian filter
+func gaussianAlpha(inCutoffPeriod float64, inPoles int) float64 {
+
+	beta := (1.0 - math.Cos(2.0*math.Pi/inCutoffPeriod)) / (math.Pow(2.0, 1.0/float64(inPoles)) - 1.0)
+	return -beta + math.Sqrt((beta*beta)+(2.0*beta))
+}
+
+// butterworth - analog Butterworth prototype mapped by the prewarped bilinear transform,
+// normalised to unit gain at the trend (low pass) or at the 2 bar cycle (high pass)
+func butterworth(inCutoffPeriod float64, inPoles int, highPass bool) Filter {
+
+	warped := math.Tan(math.Pi / inCutoffPeriod)
+	poles := make([]complex128, inPoles)
+	zeros := make([]complex128, inPoles)
+	for k := 0; k < inPoles; k++ {
+		theta := math.Pi * float64(2*k+inPoles+1) / float64(2*inPoles)
+		prototype := complex(math.Cos(theta), math.Sin(theta))
+		analog := complex(warped, 0.0) * prototype
+		zeros[k] = complex(-1.0, 0.0)
+		if highPass {
+			analog = complex(warped, 0.0) / prototype
+			zeros[k] = complex(1.0, 0.0)
+		}
+		poles[k] = (1.0 + analog) / (1.0 - analog)
+	}
+	f := Filter{B: polyFromRoots(zeros), A: polyFromRoots(poles)}
+	omega := 0.0
+	if highPass {
+		omega = math.Pi
+	}
+	gain := cmplx.Abs(f.denominator(omega, 0) / f.response(omega, 0))
+	for i := range f.B {
+		f.B[i] *= gain
+	}
+	return f
+}
+
+// polyResponse - sum k^power * c[k] * exp(-i*omega*k)
+func polyResponse(c []float64, omega float64, power int) complex128 {
+
+	sum := complex(0.0, 0.0)
+	for k, coefficient := range c {
+		sum += complex(coefficient*math.Pow(float64(k), float64(power)), 0.0) * cmplx.Exp(complex(0.0, -omega*float64(k)))
+	}
+	return sum
+}
+
+// polyFromRoots - real coefficients of prod (1 - root*z^-1), roots coming in conjugate pairs
+func polyFromRoots(roots []complex128) []float64 {
+
+	c := make([]complex128, len(roots)+1)
+	c[0] = 1.0
+	for i, root := range roots {
+		for k := i + 1; k > 0; k-- {
+			c[k] -= root * c[k-1]
+		}
+	}
+	outReal := make([]float64, len(c))
+	for k := range c {
+		outReal[k] = real(c[k])
+	}
+	return outReal
+}
+
+/* Statistic Functions */
+
+// AdfRegression - deterministic terms of the Augmented Dickey-Fuller regression
+type AdfRegression int
+
+// Kinds of ADF regressions
+const (
+	AdfNone AdfRegression = iota
+	AdfConstant
+	AdfTrend
+)
+
+// AdfResult - outcome of a unit root test, CriticalValues at the 1%, 5% and 10% levels
+type AdfResult struct {
+	Stat           float64
+	PValue         float64
+	Lags           int
+	Nobs           int
+	CriticalValues [3]float64
+}
+
+// Adf - Augmented Dickey-Fuller unit root test on the whole series
+// result = Adf(real, lags=-1, regression=AdfConstant)
+//
+// Regresses diff[t] on real[t-1], lags lagged differences and the deterministic terms; Stat is the t-statistic
+// of real[t-1]. A negative lags picks the count minimising the AIC, up to 12*(n/100)^(1/4).
+// Critical values use MacKinnon (2010) response surfaces and PValue the MacKinnon (1994) approximation.
+func Adf(inReal []float64, inLags int, inRegression AdfRegression) AdfResult {
+
+	result := adf(inReal, inLags, inRegression)
+	result.PValue = mackinnonPValue(result.Stat, 1, inRegression)
+	result.CriticalValues = mackinnonCriticalValues(result.Nobs, 1, inRegression)
+	return result
+}
+
+// Beta - Beta
+func Beta(inReal0 []float64, inReal1 []float64, inTimePeriod int) []float64 {
 
 	outReal := make([]float64, len(inReal0))
 
@@ -5101,137 +8120,1005 @@ func Correl(inReal0 []float64, inReal1 []float64, inTimePeriod int) []float64 {
 	return outReal
 }
 
-// LinearReg - Linear Regression
-func LinearReg(inReal []float64, inTimePeriod int) []float64 {
+// CorrelMatrix - Rolling Pearson correlation matrix of several series
+// out[t][i][j] is the correlation of inReals[i] and inReals[j] over the timeperiod bars ending at t
+func CorrelMatrix(inReals [][]float64, inTimePeriod int) [][][]float64 {
 
-	outReal := make([]float64, len(inReal))
+	return rollingMatrix(inReals, NewCovMatrixStream(len(inReals), inTimePeriod), inTimePeriod-1, func(s *CovMatrixStream, today int) [][]float64 {
+		return s.Correl()
+	})
+}
 
-	inTimePeriodF := float64(inTimePeriod)
-	lookbackTotal := inTimePeriod
-	startIdx := lookbackTotal
-	outIdx := startIdx - 1
-	today := startIdx - 1
-	sumX := inTimePeriodF * (inTimePeriodF - 1) * 0.5
-	sumXSqr := inTimePeriodF * (inTimePeriodF - 1) * (2*inTimePeriodF - 1) / 6
-	divisor := sumX*sumX - inTimePeriodF*sumXSqr
-	//initialize values of sumY and sumXY over first (inTimePeriod) input values
-	sumXY := 0.0
-	sumY := 0.0
-	i := inTimePeriod
-	for i != 0 {
-		i--
-		tempValue1 := inReal[today-i]
-		sumY += tempValue1
-		sumXY += float64(i) * tempValue1
+// CovMatrix - Rolling covariance matrix (population, as Var) of several series
+// out[t][i][j] is the covariance of inReals[i] and inReals[j] over the timeperiod bars ending at t
+func CovMatrix(inReals [][]float64, inTimePeriod int) [][][]float64 {
+
+	return rollingMatrix(inReals, NewCovMatrixStream(len(inReals), inTimePeriod), inTimePeriod-1, func(s *CovMatrixStream, today int) [][]float64 {
+		return s.Cov()
+	})
+}
+
+// LedoitWolfCovMatrix - Rolling covariance matrix shrunk towards a scaled identity with the Ledoit-Wolf intensity
+// cov, shrinkage = LedoitWolfCovMatrix(series, timeperiod=60)
+func LedoitWolfCovMatrix(inReals [][]float64, inTimePeriod int) ([][][]float64, []float64) {
+
+	outShrinkage := make([]float64, 0)
+	if len(inReals) > 0 {
+		outShrinkage = make([]float64, len(inReals[0]))
 	}
-	for today < len(inReal) {
-		//sumX and sumXY are already available for first output value
-		if today > startIdx-1 {
-			tempValue2 := inReal[today-inTimePeriod]
-			sumXY += sumY - inTimePeriodF*tempValue2
-			sumY += inReal[today] - tempValue2
+	outCov := rollingMatrix(inReals, NewCovMatrixStream(len(inReals), inTimePeriod), inTimePeriod-1, func(s *CovMatrixStream, today int) [][]float64 {
+		cov, shrinkage := s.LedoitWolf()
+		outShrinkage[today] = shrinkage
+		return cov
+	})
+	return outCov, outShrinkage
+}
+
+// EwmaCovMatrix - RiskMetrics exponentially weighted covariance matrix of several return series
+// out[t] = lambda*out[t-1] + (1-lambda)*r[t-1]*r[t-1]', seeded with r[0]*r[0]'; its diagonal matches EwmaVar.
+func EwmaCovMatrix(inReals [][]float64, inLambda float64) [][][]float64 {
+
+	if len(inReals) == 0 {
+		return nil
+	}
+	outMatrix := make([][][]float64, len(inReals[0]))
+	stream := NewEwmaCovMatrixStream(len(inReals), inLambda)
+	values := make([]float64, len(inReals))
+	for today := 0; today < len(outMatrix); today++ {
+		if today > 0 {
+			outMatrix[today] = stream.Cov()
+		}
+		for i := range inReals {
+			values[i] = inReals[i][today]
+		}
+		stream.Update(values)
+		if today == 0 {
+			outMatrix[today] = stream.Cov()
 		}
-		m := (inTimePeriodF*sumXY - sumX*sumY) / divisor
-		b := (sumY - m*sumX) / inTimePeriodF
-		outReal[outIdx] = b + m*(inTimePeriodF-1)
-		outIdx++
-		today++
 	}
-	return outReal
+	return outMatrix
 }
 
-// LinearRegAngle - Linear Regression Angle
-func LinearRegAngle(inReal []float64, inTimePeriod int) []float64 {
+// rollingMatrix - feeds every bar to the stream and collects fn from lookback on, zero matrices before
+func rollingMatrix(inReals [][]float64, stream *CovMatrixStream, lookback int, fn func(s *CovMatrixStream, today int) [][]float64) [][][]float64 {
 
-	outReal := make([]float64, len(inReal))
+	if len(inReals) == 0 {
+		return nil
+	}
+	outMatrix := make([][][]float64, len(inReals[0]))
+	values := make([]float64, len(inReals))
+	for today := 0; today < len(outMatrix); today++ {
+		for i := range inReals {
+			values[i] = inReals[i][today]
+		}
+		stream.Update(values)
+		if today >= lookback && lookback >= 0 {
+			outMatrix[today] = fn(stream, today)
+		} else {
+			outMatrix[today] = newMatrix(len(inReals))
+		}
+	}
+	return outMatrix
+}
 
-	inTimePeriodF := float64(inTimePeriod)
-	lookbackTotal := inTimePeriod
-	startIdx := lookbackTotal
-	outIdx := startIdx - 1
-	today := startIdx - 1
-	sumX := inTimePeriodF * (inTimePeriodF - 1) * 0.5
-	sumXSqr := inTimePeriodF * (inTimePeriodF - 1) * (2*inTimePeriodF - 1) / 6
-	divisor := sumX*sumX - inTimePeriodF*sumXSqr
-	//initialize values of sumY and sumXY over first (inTimePeriod) input values
-	sumXY := 0.0
-	sumY := 0.0
-	i := inTimePeriod
-	for i != 0 {
-		i--
-		tempValue1 := inReal[today-i]
-		sumY += tempValue1
-		sumXY += float64(i) * tempValue1
+// CovMatrixStream - incremental covariance matrix of several series, fed one bar at a time.
+// Rolling streams keep Welford means and co-moments over the window so every bar costs O(N^2) instead of
+// O(N^2 * timeperiod) without the cancellation of raw sums of products; exponentially weighted streams keep
+// the RiskMetrics recursion around a zero mean.
+type CovMatrixStream struct {
+	period  int
+	lambda  float64
+	ewma    bool
+	window  [][]float64
+	count   int
+	moments *comoments
+	ewmaCov [][]float64
+}
+
+// NewCovMatrixStream - rolling covariance of n series over the last timeperiod bars
+// A timeperiod below 1 gives a stream whose window never fills and whose matrices stay 0.
+func NewCovMatrixStream(n int, inTimePeriod int) *CovMatrixStream {
+	s := &CovMatrixStream{period: inTimePeriod, moments: newComoments(n)}
+	if inTimePeriod > 0 {
+		s.window = make([][]float64, inTimePeriod)
 	}
-	for today < len(inReal) {
-		//sumX and sumXY are already available for first output value
-		if today > startIdx-1 {
-			tempValue2 := inReal[today-inTimePeriod]
-			sumXY += sumY - inTimePeriodF*tempValue2
-			sumY += inReal[today] - tempValue2
+	return s
+}
+
+// NewEwmaCovMatrixStream - exponentially weighted covariance of n return series with decay lambda
+func NewEwmaCovMatrixStream(n int, inLambda float64) *CovMatrixStream {
+	return &CovMatrixStream{lambda: inLambda, ewma: true, moments: newComoments(n), ewmaCov: newMatrix(n)}
+}
+
+// Update adds one bar, one value per series, and reports whether the window is full
+func (s *CovMatrixStream) Update(inValues []float64) bool {
+
+	n := len(s.moments.mean)
+	if s.ewma {
+		// exponentially weighted, ewmaCov holds the covariance estimate
+		for i := 0; i < n; i++ {
+			for j := 0; j < n; j++ {
+				tempReal := inValues[i] * inValues[j]
+				if s.count == 0 {
+					s.ewmaCov[i][j] = tempReal
+				} else {
+					s.ewmaCov[i][j] = (s.lambda * s.ewmaCov[i][j]) + ((1.0 - s.lambda) * tempReal)
+				}
+			}
 		}
-		m := (inTimePeriodF*sumXY - sumX*sumY) / divisor
-		outReal[outIdx] = math.Atan(m) * (180.0 / math.Pi)
-		outIdx++
-		today++
+		s.count++
+		return true
 	}
-	return outReal
+	if s.period < 1 {
+		return false
+	}
+
+	slot := s.count % s.period
+	if trailing := s.window[slot]; trailing != nil {
+		s.moments.remove(trailing)
+	}
+	observation := append([]float64(nil), inValues[:n]...)
+	s.window[slot] = observation
+	s.moments.add(observation)
+	s.count++
+	return s.count >= s.period
 }
 
-// LinearRegIntercept - Linear Regression Intercept
-func LinearRegIntercept(inReal []float64, inTimePeriod int) []float64 {
+// Cov returns the current covariance matrix
+func (s *CovMatrixStream) Cov() [][]float64 {
 
-	outReal := make([]float64, len(inReal))
+	n := len(s.moments.mean)
+	outMatrix := newMatrix(n)
+	if s.ewma {
+		for i := 0; i < n; i++ {
+			copy(outMatrix[i], s.ewmaCov[i])
+		}
+		return outMatrix
+	}
+	if s.moments.count == 0 {
+		return outMatrix
+	}
+	countF := float64(s.moments.count)
+	for i := 0; i < n; i++ {
+		for j := i; j < n; j++ {
+			tempReal := s.moments.c[i][j] / countF
+			outMatrix[i][j] = tempReal
+			outMatrix[j][i] = tempReal
+		}
+	}
+	return outMatrix
+}
 
-	inTimePeriodF := float64(inTimePeriod)
-	lookbackTotal := inTimePeriod
-	startIdx := lookbackTotal
-	outIdx := startIdx - 1
-	today := startIdx - 1
-	sumX := inTimePeriodF * (inTimePeriodF - 1) * 0.5
-	sumXSqr := inTimePeriodF * (inTimePeriodF - 1) * (2*inTimePeriodF - 1) / 6
-	divisor := sumX*sumX - inTimePeriodF*sumXSqr
-	//initialize values of sumY and sumXY over first (inTimePeriod) input values
-	sumXY := 0.0
-	sumY := 0.0
-	i := inTimePeriod
-	for i != 0 {
-		i--
-		tempValue1 := inReal[today-i]
-		sumY += tempValue1
-		sumXY += float64(i) * tempValue1
+// Correl returns the current correlation matrix, 0 for pairs involving a constant series
+func (s *CovMatrixStream) Correl() [][]float64 {
+
+	outMatrix := s.Cov()
+	n := len(outMatrix)
+	variance := make([]float64, n)
+	for i := 0; i < n; i++ {
+		variance[i] = outMatrix[i][i]
 	}
-	for today < len(inReal) {
-		//sumX and sumXY are already available for first output value
-		if today > startIdx-1 {
-			tempValue2 := inReal[today-inTimePeriod]
-			sumXY += sumY - inTimePeriodF*tempValue2
-			sumY += inReal[today] - tempValue2
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			tempReal := variance[i] * variance[j]
+			if !(tempReal < 0.00000000000001) {
+				outMatrix[i][j] /= math.Sqrt(tempReal)
+			} else {
+				outMatrix[i][j] = 0.0
+			}
 		}
-		m := (inTimePeriodF*sumXY - sumX*sumY) / divisor
-		outReal[outIdx] = (sumY - m*sumX) / inTimePeriodF
-		outIdx++
-		today++
 	}
-	return outReal
+	return outMatrix
 }
 
-// LinearRegSlope - Linear Regression Slope
-func LinearRegSlope(inReal []float64, inTimePeriod int) []float64 {
+// LedoitWolf returns the covariance of the current window shrunk towards mu*I, mu being the average variance,
+// together with the Ledoit-Wolf optimal shrinkage intensity. Only available for rolling streams.
+func (s *CovMatrixStream) LedoitWolf() ([][]float64, float64) {
 
-	outReal := make([]float64, len(inReal))
+	cov := s.Cov()
+	if s.ewma || s.period < 1 || s.count == 0 {
+		return cov, 0.0
+	}
+	n := len(cov)
+	countF := float64(s.moments.count)
 
-	inTimePeriodF := float64(inTimePeriod)
-	lookbackTotal := inTimePeriod
-	startIdx := lookbackTotal
-	outIdx := startIdx - 1
-	today := startIdx - 1
-	sumX := inTimePeriodF * (inTimePeriodF - 1) * 0.5
-	sumXSqr := inTimePeriodF * (inTimePeriodF - 1) * (2*inTimePeriodF - 1) / 6
-	divisor := sumX*sumX - inTimePeriodF*sumXSqr
-	//initialize values of sumY and sumXY over first (inTimePeriod) input values
-	sumXY := 0.0
-	sumY := 0.0
+	mu := 0.0
+	for i := 0; i < n; i++ {
+		mu += cov[i][i]
+	}
+	mu /= float64(n)
+
+	// beta: dispersion of the individual outer products around the sample covariance
+	beta := 0.0
+	centered := make([]float64, n)
+	for _, observation := range s.window {
+		if observation == nil {
+			continue
+		}
+		for i := 0; i < n; i++ {
+			centered[i] = observation[i] - s.moments.mean[i]
+		}
+		for i := 0; i < n; i++ {
+			for j := 0; j < n; j++ {
+				tempReal := (centered[i] * centered[j]) - cov[i][j]
+				beta += tempReal * tempReal
+			}
+		}
+	}
+	beta /= countF * countF
+
+	// delta: distance of the sample covariance to the target
+	delta := 0.0
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			tempReal := cov[i][j]
+			if i == j {
+				tempReal -= mu
+			}
+			delta += tempReal * tempReal
+		}
+	}
+
+	shrinkage := 0.0
+	if delta > 0.0 {
+		shrinkage = math.Min(beta, delta) / delta
+	}
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			cov[i][j] *= 1.0 - shrinkage
+		}
+		cov[i][i] += shrinkage * mu
+	}
+	return cov, shrinkage
+}
+
+// newMatrix - n x n matrix of zeros
+func newMatrix(n int) [][]float64 {
+	outMatrix := make([][]float64, n)
+	for i := range outMatrix {
+		outMatrix[i] = make([]float64, n)
+	}
+	return outMatrix
+}
+
+// EngleGranger - Engle-Granger two-step cointegration test of inY on inX
+// result, hedgeRatio, intercept = EngleGranger(y, x, lags=-1)
+//
+// The cointegrating regression y = intercept + hedgeRatio*x runs over the whole sample and its residuals
+// go through Adf without deterministic terms, judged against MacKinnon's two variable critical values.
+func EngleGranger(inY []float64, inX []float64, inLags int) (AdfResult, float64, float64) {
+
+	stream := NewOlsStream(1, len(inY))
+	for today := range inY {
+		stream.Update(inY[today], []float64{inX[today]})
+	}
+	fit := stream.Fit()
+	if fit.Coef == nil {
+		return AdfResult{}, 0.0, 0.0
+	}
+	residual := make([]float64, len(inY))
+	for today := range inY {
+		residual[today] = inY[today] - fit.Coef[0] - (fit.Coef[1] * inX[today])
+	}
+
+	result := adf(residual, inLags, AdfNone)
+	result.PValue = mackinnonPValue(result.Stat, 2, AdfConstant)
+	result.CriticalValues = mackinnonCriticalValues(result.Nobs, 2, AdfConstant)
+	return result, fit.Coef[1], fit.Coef[0]
+}
+
+// EwmaVar - RiskMetrics exponentially weighted variance of a return series
+// variance, forecast = EwmaVar(returns, lambda=0.94, steps=10)
+//
+// variance[t] = lambda*variance[t-1] + (1-lambda)*returns[t-1]^2 is the variance of returns[t] known at t-1,
+// seeded with returns[0]^2. The forecast for the next steps bars is flat at the one step ahead estimate.
+// inReal are returns, e.g. Returns(close, LogReturns)[1:].
+func EwmaVar(inReal []float64, inLambda float64, inSteps int) ([]float64, []float64) {
+
+	outVariance := make([]float64, len(inReal))
+	outForecast := make([]float64, inSteps)
+
+	if len(inReal) == 0 {
+		return outVariance, outForecast
+	}
+
+	outVariance[0] = inReal[0] * inReal[0]
+	for today := 1; today < len(inReal); today++ {
+		tempReal := inReal[today-1]
+		outVariance[today] = (inLambda * outVariance[today-1]) + ((1.0 - inLambda) * tempReal * tempReal)
+	}
+	tempReal := inReal[len(inReal)-1]
+	nextVariance := (inLambda * outVariance[len(inReal)-1]) + ((1.0 - inLambda) * tempReal * tempReal)
+	for i := range outForecast {
+		outForecast[i] = nextVariance
+	}
+	return outVariance, outForecast
+}
+
+// GarchModel - GARCH(1,1) model: variance[t] = Omega + Alpha*returns[t-1]^2 + Beta*variance[t-1]
+type GarchModel struct {
+	Omega         float64
+	Alpha         float64
+	Beta          float64
+	LogLikelihood float64
+}
+
+// Garch - GARCH(1,1) conditional variance fitted by maximum likelihood
+// variance, forecast, model = Garch(returns, steps=10)
+//
+// variance[t] is the variance of returns[t] known at t-1, forecast holds the variance of the next steps bars.
+// inReal are zero mean returns, e.g. Returns(close, LogReturns)[1:].
+func Garch(inReal []float64, inSteps int) ([]float64, []float64, GarchModel) {
+
+	model := GarchFit(inReal)
+	return model.Variance(inReal), model.Forecast(inReal, inSteps), model
+}
+
+// GarchFit - maximum likelihood estimate of a GARCH(1,1) model for a return series
+// The likelihood is maximized with a Nelder-Mead search over a parametrization keeping
+// Omega > 0, Alpha >= 0, Beta >= 0 and Alpha+Beta < 1.
+func GarchFit(inReal []float64) GarchModel {
+
+	if len(inReal) < 2 {
+		return GarchModel{}
+	}
+	sampleVariance := garchBackcast(inReal)
+	if !(sampleVariance > 0.0) {
+		return GarchModel{}
+	}
+
+	toModel := func(x []float64) GarchModel {
+		norm := 1.0 + math.Exp(x[1]) + math.Exp(x[2])
+		return GarchModel{
+			Omega: sampleVariance * math.Exp(x[0]),
+			Alpha: math.Exp(x[1]) / norm,
+			Beta:  math.Exp(x[2]) / norm,
+		}
+	}
+	negLogLikelihood := func(x []float64) float64 {
+		ll := toModel(x).logLikelihood(inReal)
+		if math.IsNaN(ll) || math.IsInf(ll, 0) {
+			return math.MaxFloat64
+		}
+		return -ll
+	}
+
+	// start from alpha=0.05, beta=0.90 with the variance targeting omega
+	start := []float64{math.Log(0.05), 0.0, math.Log(0.90 / 0.05)}
+	best := nelderMead(negLogLikelihood, start, 2000, 0.000000001)
+	model := toModel(best)
+	model.LogLikelihood = model.logLikelihood(inReal)
+	return model
+}
+
+// Variance returns the conditional variance series of the model over a return series
+func (m GarchModel) Variance(inReal []float64) []float64 {
+
+	outReal := make([]float64, len(inReal))
+
+	if len(inReal) == 0 {
+		return outReal
+	}
+	outReal[0] = garchBackcast(inReal)
+	for today := 1; today < len(inReal); today++ {
+		tempReal := inReal[today-1]
+		outReal[today] = m.Omega + (m.Alpha * tempReal * tempReal) + (m.Beta * outReal[today-1])
+	}
+	return outReal
+}
+
+// Forecast returns the variance forecasts for the inSteps bars following the return series
+func (m GarchModel) Forecast(inReal []float64, inSteps int) []float64 {
+
+	outReal := make([]float64, inSteps)
+
+	if len(inReal) == 0 || inSteps < 1 {
+		return outReal
+	}
+	variance := m.Variance(inReal)
+	tempReal := inReal[len(inReal)-1]
+	outReal[0] = m.Omega + (m.Alpha * tempReal * tempReal) + (m.Beta * variance[len(variance)-1])
+	for i := 1; i < inSteps; i++ {
+		outReal[i] = m.Omega + ((m.Alpha + m.Beta) * outReal[i-1])
+	}
+	return outReal
+}
+
+// logLikelihood - gaussian log likelihood of the return series under the model
+func (m GarchModel) logLikelihood(inReal []float64) float64 {
+
+	ll := 0.0
+	variance := garchBackcast(inReal)
+	for today := 0; today < len(inReal); today++ {
+		if today > 0 {
+			tempReal := inReal[today-1]
+			variance = m.Omega + (m.Alpha * tempReal * tempReal) + (m.Beta * variance)
+		}
+		if !(variance > 0.0) {
+			return math.Inf(-1)
+		}
+		ll -= 0.5 * (math.Log(2.0*math.Pi) + math.Log(variance) + (inReal[today]*inReal[today])/variance)
+	}
+	return ll
+}
+
+// garchBackcast - mean squared return, used as the variance before the first observation
+func garchBackcast(inReal []float64) float64 {
+
+	sum := 0.0
+	for _, tempReal := range inReal {
+		sum += tempReal * tempReal
+	}
+	return sum / float64(len(inReal))
+}
+
+// nelderMead - minimizes f with the Nelder-Mead simplex method starting from x0
+func nelderMead(f func([]float64) float64, x0 []float64, maxIter int, tolerance float64) []float64 {
+
+	n := len(x0)
+	simplex := make([][]float64, n+1)
+	values := make([]float64, n+1)
+	for i := range simplex {
+		simplex[i] = append([]float64(nil), x0...)
+		if i > 0 {
+			simplex[i][i-1] += 0.5
+		}
+		values[i] = f(simplex[i])
+	}
+
+	point := func(base []float64, dir []float64, scale float64) []float64 {
+		p := make([]float64, n)
+		for j := range p {
+			p[j] = base[j] + scale*(dir[j]-base[j])
+		}
+		return p
+	}
+
+	for iter := 0; iter < maxIter; iter++ {
+		// order the vertices, best first
+		for i := 1; i <= n; i++ {
+			for j := i; j > 0 && values[j] < values[j-1]; j-- {
+				simplex[j], simplex[j-1] = simplex[j-1], simplex[j]
+				values[j], values[j-1] = values[j-1], values[j]
+			}
+		}
+		if math.Abs(values[n]-values[0]) <= tolerance*(math.Abs(values[0])+tolerance) {
+			break
+		}
+
+		centroid := make([]float64, n)
+		for i := 0; i < n; i++ {
+			for j := range centroid {
+				centroid[j] += simplex[i][j] / float64(n)
+			}
+		}
+
+		reflected := point(centroid, simplex[n], -1.0)
+		reflectedValue := f(reflected)
+		switch {
+		case reflectedValue < values[0]:
+			expanded := point(centroid, simplex[n], -2.0)
+			if expandedValue := f(expanded); expandedValue < reflectedValue {
+				simplex[n], values[n] = expanded, expandedValue
+			} else {
+				simplex[n], values[n] = reflected, reflectedValue
+			}
+		case reflectedValue < values[n-1]:
+			simplex[n], values[n] = reflected, reflectedValue
+		default:
+			contracted := point(centroid, simplex[n], 0.5)
+			if contractedValue := f(contracted); contractedValue < values[n] {
+				simplex[n], values[n] = contracted, contractedValue
+			} else {
+				// shrink towards the best vertex
+				for i := 1; i <= n; i++ {
+					simplex[i] = point(simplex[0], simplex[i], 0.5)
+					values[i] = f(simplex[i])
+				}
+			}
+		}
+	}
+
+	bestIdx := 0
+	for i := range values {
+		if values[i] < values[bestIdx] {
+			bestIdx = i
+		}
+	}
+	return simplex[bestIdx]
+}
+
+// HalfLife - Rolling Ornstein-Uhlenbeck half-life of a spread, in bars
+// Regresses diff[t] = a + b*real[t-1] over the last timeperiod differences: half-life = -ln(2)/ln(1+b).
+// +Inf when the spread does not revert (b >= 0), 0 when it overshoots within a bar (b <= -1).
+func HalfLife(inReal []float64, inTimePeriod int) []float64 {
+
+	outReal := make([]float64, len(inReal))
+
+	if inTimePeriod < 1 {
+		return outReal
+	}
+
+	window := make([][]float64, inTimePeriod)
+	moments := newComoments(2)
+	for today := 1; today < len(inReal); today++ {
+		slot := (today - 1) % inTimePeriod
+		if window[slot] != nil {
+			moments.remove(window[slot])
+		}
+		window[slot] = []float64{inReal[today-1], inReal[today] - inReal[today-1]}
+		moments.add(window[slot])
+		if today < inTimePeriod {
+			continue
+		}
+		if !(moments.c[0][0] > 0.0) {
+			outReal[today] = math.Inf(1)
+			continue
+		}
+		slope := moments.c[0][1] / moments.c[0][0]
+		if slope >= 0.0 {
+			outReal[today] = math.Inf(1)
+		} else if slope > -1.0 {
+			outReal[today] = -math.Ln2 / math.Log(1.0+slope)
+		}
+	}
+	return outReal
+}
+
+// HedgeMethod - how the hedge ratio of a pair is estimated
+type HedgeMethod int
+
+// Kinds of hedge ratios
+const (
+	HedgeOls HedgeMethod = iota
+	HedgeTls
+)
+
+// HedgeRatio - Rolling hedge ratio and intercept of inY against inX
+// ratio, intercept = HedgeRatio(y, x, timeperiod=60, method=HedgeOls)
+//
+// HedgeOls regresses y on x; HedgeTls minimises orthogonal distances (total least squares) and so treats
+// both legs symmetrically: the ratio of x on y is the reciprocal of the ratio of y on x.
+func HedgeRatio(inY []float64, inX []float64, inTimePeriod int, inMethod HedgeMethod) ([]float64, []float64) {
+
+	outRatio := make([]float64, len(inY))
+	outIntercept := make([]float64, len(inY))
+
+	if inTimePeriod < 1 {
+		return outRatio, outIntercept
+	}
+
+	window := make([][]float64, inTimePeriod)
+	moments := newComoments(2)
+	for today := 0; today < len(inY); today++ {
+		slot := today % inTimePeriod
+		if window[slot] != nil {
+			moments.remove(window[slot])
+		}
+		window[slot] = []float64{inX[today], inY[today]}
+		moments.add(window[slot])
+		if today < inTimePeriod-1 {
+			continue
+		}
+		sxx, sxy, syy := moments.c[0][0], moments.c[0][1], moments.c[1][1]
+		ratio := 0.0
+		if inMethod == HedgeTls {
+			if sxy != 0.0 {
+				tempReal := syy - sxx
+				ratio = (tempReal + math.Sqrt((tempReal*tempReal)+(4.0*sxy*sxy))) / (2.0 * sxy)
+			}
+		} else if sxx > 0.0 {
+			ratio = sxy / sxx
+		}
+		outRatio[today] = ratio
+		outIntercept[today] = moments.mean[1] - (ratio * moments.mean[0])
+	}
+	return outRatio, outIntercept
+}
+
+// KalmanBeta - Dynamic regression of inY on inX by Kalman filter: y = alpha + beta*x + noise
+// beta, alpha, variance, innovation, innovationvariance = KalmanBeta(y, x, processnoise=0.0001, observationnoise=0.001)
+//
+// alpha and beta follow independent random walks with variance processnoise per bar, y is observed with variance
+// observationnoise. variance is the filtered variance of beta; innovation is y minus the prediction from the
+// previous estimates and innovationvariance its variance, so innovation/sqrt(innovationvariance) is a spread z-score.
+// The states start from an approximately diffuse prior (variance 1e6).
+func KalmanBeta(inY []float64, inX []float64, inProcessNoise float64, inObservationNoise float64) ([]float64, []float64, []float64, []float64, []float64) {
+
+	outBeta := make([]float64, len(inY))
+	outAlpha := make([]float64, len(inY))
+	outVariance := make([]float64, len(inY))
+	outInnovation := make([]float64, len(inY))
+	outInnovationVariance := make([]float64, len(inY))
+
+	alpha, beta := 0.0, 0.0
+	p00, p01, p11 := 1000000.0, 0.0, 1000000.0
+	for today := 0; today < len(inY); today++ {
+		x := inX[today]
+		p00 += inProcessNoise
+		p11 += inProcessNoise
+		innovation := inY[today] - (alpha + (beta * x))
+		h0 := p00 + (x * p01)
+		h1 := p01 + (x * p11)
+		tempReal := h0 + (x * h1) + inObservationNoise
+		gain0 := h0 / tempReal
+		gain1 := h1 / tempReal
+		alpha += gain0 * innovation
+		beta += gain1 * innovation
+		p00 -= gain0 * h0
+		p01 -= gain0 * h1
+		p11 -= gain1 * h1
+		outBeta[today] = beta
+		outAlpha[today] = alpha
+		outVariance[today] = p11
+		outInnovation[today] = innovation
+		outInnovationVariance[today] = tempReal
+	}
+	return outBeta, outAlpha, outVariance, outInnovation, outInnovationVariance
+}
+
+// Kurt - Rolling excess kurtosis (population moments): m4/m2^2 - 3
+func Kurt(inReal []float64, inTimePeriod int) []float64 {
+
+	outReal := make([]float64, len(inReal))
+
+	rollingMoments(inReal, inTimePeriod, func(today int, mean float64, m2 float64, m3 float64, m4 float64) {
+		if !(m2 < 0.00000000000001) {
+			outReal[today] = (m4 / (m2 * m2)) - 3.0
+		}
+	})
+	return outReal
+}
+
+// LinearReg - Linear Regression
+func LinearReg(inReal []float64, inTimePeriod int) []float64 {
+
+	outReal := make([]float64, len(inReal))
+
+	inTimePeriodF := float64(inTimePeriod)
+	lookbackTotal := inTimePeriod
+	startIdx := lookbackTotal
+	outIdx := startIdx - 1
+	today := startIdx - 1
+	sumX := inTimePeriodF * (inTimePeriodF - 1) * 0.5
+	sumXSqr := inTimePeriodF * (inTimePeriodF - 1) * (2*inTimePeriodF - 1) / 6
+	divisor := sumX*sumX - inTimePeriodF*sumXSqr
+	//initialize values of sumY and sumXY over first (inTimePeriod) input values
+	sumXY := 0.0
+	sumY := 0.0
+	i := inTimePeriod
+	for i != 0 {
+		i--
+		tempValue1 := inReal[today-i]
+		sumY += tempValue1
+		sumXY += float64(i) * tempValue1
+	}
+	for today < len(inReal) {
+		//sumX and sumXY are already available for first output value
+		if today > startIdx-1 {
+			tempValue2 := inReal[today-inTimePeriod]
+			sumXY += sumY - inTimePeriodF*tempValue2
+			sumY += inReal[today] - tempValue2
+		}
+		m := (inTimePeriodF*sumXY - sumX*sumY) / divisor
+		b := (sumY - m*sumX) / inTimePeriodF
+		outReal[outIdx] = b + m*(inTimePeriodF-1)
+		outIdx++
+		today++
+	}
+	return outReal
+}
+
+// LinearRegAngle - Linear Regression Angle
+func LinearRegAngle(inReal []float64, inTimePeriod int) []float64 {
+
+	outReal := make([]float64, len(inReal))
+
+	inTimePeriodF := float64(inTimePeriod)
+	lookbackTotal := inTimePeriod
+	startIdx := lookbackTotal
+	outIdx := startIdx - 1
+	today := startIdx - 1
+	sumX := inTimePeriodF * (inTimePeriodF - 1) * 0.5
+	sumXSqr := inTimePeriodF * (inTimePeriodF - 1) * (2*inTimePeriodF - 1) / 6
+	divisor := sumX*sumX - inTimePeriodF*sumXSqr
+	//initialize values of sumY and sumXY over first (inTimePeriod) input values
+	sumXY := 0.0
+	sumY := 0.0
+	i := inTimePeriod
+	for i != 0 {
+		i--
+		tempValue1 := inReal[today-i]
+		sumY += tempValue1
+		sumXY += float64(i) * tempValue1
+	}
+	for today < len(inReal) {
+		//sumX and sumXY are already available for first output value
+		if today > startIdx-1 {
+			tempValue2 := inReal[today-inTimePeriod]
+			sumXY += sumY - inTimePeriodF*tempValue2
+			sumY += inReal[today] - tempValue2
+		}
+		m := (inTimePeriodF*sumXY - sumX*sumY) / divisor
+		outReal[outIdx] = math.Atan(m) * (180.0 / math.Pi)
+		outIdx++
+		today++
+	}
+	return outReal
+}
+
+// LinearRegIntercept - Linear Regression Intercept
+func LinearRegIntercept(inReal []float64, inTimePeriod int) []float64 {
+
+	outReal := make([]float64, len(inReal))
+
+	inTimePeriodF := float64(inTimePeriod)
+	lookbackTotal := inTimePeriod
+	startIdx := lookbackTotal
+	outIdx := startIdx - 1
+	today := startIdx - 1
+	sumX := inTimePeriodF * (inTimePeriodF - 1) * 0.5
+	sumXSqr := inTimePeriodF * (inTimePeriodF - 1) * (2*inTimePeriodF - 1) / 6
+	divisor := sumX*sumX - inTimePeriodF*sumXSqr
+	//initialize values of sumY and sumXY over first (inTimePeriod) input values
+	sumXY := 0.0
+	sumY := 0.0
+	i := inTimePeriod
+	for i != 0 {
+		i--
+		tempValue1 := inReal[today-i]
+		sumY += tempValue1
+		sumXY += float64(i) * tempValue1
+	}
+	for today < len(inReal) {
+		//sumX and sumXY are already available for first output value
+		if today > startIdx-1 {
+			tempValue2 := inReal[today-inTimePeriod]
+			sumXY += sumY - inTimePeriodF*tempValue2
+			sumY += inReal[today] - tempValue2
+		}
+		m := (inTimePeriodF*sumXY - sumX*sumY) / divisor
+		outReal[outIdx] = (sumY - m*sumX) / inTimePeriodF
+		outIdx++
+		today++
+	}
+	return outReal
+}
+
+// LinearRegSlope - Linear Regression Slope
+func LinearRegSlope(inReal []float64, inTimePeriod int) []float64 {
+
+	outReal := make([]float64, len(inReal))
+
+	inTimePeriodF := float64(inTimePeriod)
+	lookbackTotal := inTimePeriod
+	startIdx := lookbackTotal
+	outIdx := startIdx - 1
+	today := startIdx - 1
+	sumX := inTimePeriodF * (inTimePeriodF - 1) * 0.5
+	sumXSqr := inTimePeriodF * (inTimePeriodF - 1) * (2*inTimePeriodF - 1) / 6
+	divisor := sumX*sumX - inTimePeriodF*sumXSqr
+	//initialize values of sumY and sumXY over first (inTimePeriod) input values
+	sumXY := 0.0
+	sumY := 0.0
+	i := inTimePeriod
+	for i != 0 {
+		i--
+		tempValue1 := inReal[today-i]
+		sumY += tempValue1
+		sumXY += float64(i) * tempValue1
+	}
+	for today < len(inReal) {
+		//sumX and sumXY are already available for first output value
+		if today > startIdx-1 {
+			tempValue2 := inReal[today-inTimePeriod]
+			sumXY += sumY - inTimePeriodF*tempValue2
+			sumY += inReal[today] - tempValue2
+		}
+		outReal[outIdx] = (inTimePeriodF*sumXY - sumX*sumY) / divisor
+		outIdx++
+		today++
+	}
+	return outReal
+}
+
+// Median - Rolling median
+func Median(inReal []float64, inTimePeriod int) []float64 {
+	return Quantile(inReal, inTimePeriod, 0.5)
+}
+
+// PercentRank - percentage of the previous timeperiod values that are below the current value
+func PercentRank(inReal []float64, inTimePeriod int) []float64 {
+
+	outReal := make([]float64, len(inReal))
+
+	if inTimePeriod < 1 {
+		return outReal
+	}
+
+	window := &orderStatTree{}
+	for today := 0; today < len(inReal); today++ {
+		if today >= inTimePeriod {
+			outReal[today] = 100.0 * float64(window.countLess(inReal[today])) / float64(inTimePeriod)
+			window.remove(inReal[today-inTimePeriod])
+		}
+		window.insert(inReal[today])
+	}
+	return outReal
+}
+
+// Quantile - Rolling quantile, linearly interpolated between order statistics
+// real = Quantile(close, timeperiod=20, quantile=0.9)
+func Quantile(inReal []float64, inTimePeriod int, inQuantile float64) []float64 {
+
+	outReal := make([]float64, len(inReal))
+
+	if inTimePeriod < 1 {
+		return outReal
+	}
+	if inQuantile < 0.0 {
+		inQuantile = 0.0
+	} else if inQuantile > 1.0 {
+		inQuantile = 1.0
+	}
+
+	position := float64(inTimePeriod-1) * inQuantile
+	lowerRank := int(math.Floor(position))
+	fraction := position - float64(lowerRank)
+	window := &orderStatTree{}
+	for today := 0; today < len(inReal); today++ {
+		window.insert(inReal[today])
+		if today >= inTimePeriod {
+			window.remove(inReal[today-inTimePeriod])
+		}
+		if today >= inTimePeriod-1 {
+			lower := window.kth(lowerRank)
+			if fraction > 0.0 {
+				outReal[today] = lower + fraction*(window.kth(lowerRank+1)-lower)
+			} else {
+				outReal[today] = lower
+			}
+		}
+	}
+	return outReal
+}
+
+// RollingOls - Rolling multiple linear regression of inY on the inX regressors plus an intercept
+// coef, stderr, tstat, rsquared, residual = RollingOls(y, [x1, x2], timeperiod=60)
+//
+// coef, stderr and tstat hold one series per coefficient, the intercept first and then one per regressor.
+// residual is y minus the fitted value of the bar itself. Standard errors need more bars than coefficients
+// and are 0 otherwise; every output is 0 while the regressors are collinear over the window.
+func RollingOls(inY []float64, inX [][]float64, inTimePeriod int) ([][]float64, [][]float64, [][]float64, []float64, []float64) {
+
+	k := len(inX) + 1
+	outCoef := make([][]float64, k)
+	outStdErr := make([][]float64, k)
+	outTStat := make([][]float64, k)
+	for j := 0; j < k; j++ {
+		outCoef[j] = make([]float64, len(inY))
+		outStdErr[j] = make([]float64, len(inY))
+		outTStat[j] = make([]float64, len(inY))
+	}
+	outRSquared := make([]float64, len(inY))
+	outResidual := make([]float64, len(inY))
+
+	if inTimePeriod < 1 {
+		return outCoef, outStdErr, outTStat, outRSquared, outResidual
+	}
+
+	stream := NewOlsStream(len(inX), inTimePeriod)
+	values := make([]float64, len(inX))
+	for today := 0; today < len(inY); today++ {
+		for i := range inX {
+			values[i] = inX[i][today]
+		}
+		if !stream.Update(inY[today], values) {
+			continue
+		}
+		fit := stream.Fit()
+		if fit.Coef == nil {
+			continue
+		}
+		fitted := fit.Coef[0]
+		for i := range values {
+			fitted += fit.Coef[i+1] * values[i]
+		}
+		for j := 0; j < k; j++ {
+			outCoef[j][today] = fit.Coef[j]
+			outStdErr[j][today] = fit.StdErr[j]
+			outTStat[j][today] = fit.TStat[j]
+		}
+		outRSquared[today] = fit.RSquared
+		outResidual[today] = inY[today] - fitted
+	}
+	return outCoef, outStdErr, outTStat, outRSquared, outResidual
+}
+
+// Skew - Rolling skewness (population moments): m3/m2^1.5
+func Skew(inReal []float64, inTimePeriod int) []float64 {
+
+	outReal := make([]float64, len(inReal))
+
+	rollingMoments(inReal, inTimePeriod, func(today int, mean float64, m2 float64, m3 float64, m4 float64) {
+		if !(m2 < 0.00000000000001) {
+			outReal[today] = m3 / math.Pow(m2, 1.5)
+		}
+	})
+	return outReal
+}
+
+// Spread - Spread of a pair: y - hedgeRatio*x
+func Spread(inY []float64, inX []float64, inHedgeRatio []float64) []float64 {
+
+	outReal := make([]float64, len(inY))
+	for today := range inY {
+		outReal[today] = inY[today] - (inHedgeRatio[today] * inX[today])
+	}
+	return outReal
+}
+
+// SpreadZScore - Spread of a pair under its rolling hedge ratio and the rolling z-score of that spread
+// spread, zscore = SpreadZScore(y, x, hedgeperiod=60, zperiod=20, method=HedgeOls)
+func SpreadZScore(inY []float64, inX []float64, inHedgePeriod int, inZPeriod int, inMethod HedgeMethod) ([]float64, []float64) {
+
+	outSpread := make([]float64, len(inY))
+	outZScore := make([]float64, len(inY))
+
+	startIdx := inHedgePeriod - 1
+	if inHedgePeriod < 1 || startIdx >= len(inY) {
+		return outSpread, outZScore
+	}
+	ratio, _ := HedgeRatio(inY, inX, inHedgePeriod, inMethod)
+	spread := Spread(inY[startIdx:], inX[startIdx:], ratio[startIdx:])
+	copy(outSpread[startIdx:], spread)
+	copy(outZScore[startIdx:], ZScore(spread, inZPeriod))
+	return outSpread, outZScore
+}
+
+// StdDev - Standard Deviation
+func StdDev(inReal []float64, inTimePeriod int, inNbDev float64) []float64 {
+
+	outReal := Var(inReal, inTimePeriod)
+
+	if inNbDev != 1.0 {
+		for i := 0; i < len(inReal); i++ {
+			tempReal := outReal[i]
+			if !(tempReal < 0.00000000000001) {
+				outReal[i] = math.Sqrt(tempReal) * inNbDev
+			} else {
+				outReal[i] = 0.0
+			}
+		}
+	} else {
+		for i := 0; i < len(inReal); i++ {
+			tempReal := outReal[i]
+			if !(tempReal < 0.00000000000001) {
+				outReal[i] = math.Sqrt(tempReal)
+			} else {
+				outReal[i] = 0.0
+			}
+		}
+	}
+	return outReal
+}
+
+// Tsf - Time Series Forecast
+func Tsf(inReal []float64, inTimePeriod int) []float64 {
+
+	outReal := make([]float64, len(inReal))
+
+	inTimePeriodF := float64(inTimePeriod)
+	lookbackTotal := inTimePeriod
+	startIdx := lookbackTotal
+	outIdx := startIdx - 1
+	today := startIdx - 1
+	sumX := inTimePeriodF * (inTimePeriodF - 1.0) * 0.5
+	sumXSqr := inTimePeriodF * (inTimePeriodF - 1) * (2*inTimePeriodF - 1) / 6
+	divisor := sumX*sumX - inTimePeriodF*sumXSqr
+	//initialize values of sumY and sumXY over first (inTimePeriod) input values
+	sumXY := 0.0
+	sumY := 0.0
 	i := inTimePeriod
 	for i != 0 {
 		i--
@@ -5239,125 +9126,567 @@ func LinearRegSlope(inReal []float64, inTimePeriod int) []float64 {
 		sumY += tempValue1
 		sumXY += float64(i) * tempValue1
 	}
-	for today < len(inReal) {
-		//sumX and sumXY are already available for first output value
-		if today > startIdx-1 {
-			tempValue2 := inReal[today-inTimePeriod]
-			sumXY += sumY - inTimePeriodF*tempValue2
-			sumY += inReal[today] - tempValue2
+	for today < len(inReal) {
+		//sumX and sumXY are already available for first output value
+		if today > startIdx-1 {
+			tempValue2 := inReal[today-inTimePeriod]
+			sumXY += sumY - inTimePeriodF*tempValue2
+			sumY += inReal[today] - tempValue2
+		}
+		m := (inTimePeriodF*sumXY - sumX*sumY) / divisor
+		b := (sumY - m*sumX) / inTimePeriodF
+		outReal[outIdx] = b + m*inTimePeriodF
+		today++
+		outIdx++
+	}
+	return outReal
+}
+
+// Var - Variance
+func Var(inReal []float64, inTimePeriod int) []float64 {
+
+	outReal := make([]float64, len(inReal))
+
+	nbInitialElementNeeded := inTimePeriod - 1
+	startIdx := nbInitialElementNeeded
+	periodTotal1 := 0.0
+	periodTotal2 := 0.0
+	trailingIdx := startIdx - nbInitialElementNeeded
+	i := trailingIdx
+	if inTimePeriod > 1 {
+		for i < startIdx {
+			tempReal := inReal[i]
+			periodTotal1 += tempReal
+			tempReal *= tempReal
+			periodTotal2 += tempReal
+			i++
+		}
+	}
+	outIdx := startIdx
+	for ok := true; ok; {
+		tempReal := inReal[i]
+		periodTotal1 += tempReal
+		tempReal *= tempReal
+		periodTotal2 += tempReal
+		meanValue1 := periodTotal1 / float64(inTimePeriod)
+		meanValue2 := periodTotal2 / float64(inTimePeriod)
+		tempReal = inReal[trailingIdx]
+		periodTotal1 -= tempReal
+		tempReal *= tempReal
+		periodTotal2 -= tempReal
+		outReal[outIdx] = meanValue2 - meanValue1*meanValue1
+		i++
+		trailingIdx++
+		outIdx++
+		ok = i < len(inReal)
+	}
+	return outReal
+}
+
+// ZScore - Rolling z-score: (value - mean) / standard deviation over the period
+func ZScore(inReal []float64, inTimePeriod int) []float64 {
+
+	outReal := make([]float64, len(inReal))
+
+	rollingMoments(inReal, inTimePeriod, func(today int, mean float64, m2 float64, m3 float64, m4 float64) {
+		if !(m2 < 0.00000000000001) {
+			outReal[today] = (inReal[today] - mean) / math.Sqrt(m2)
+		}
+	})
+	return outReal
+}
+
+// rollingMoments - calls fn with the mean and the central moments m2, m3, m4 of every full window.
+// Each window is recomputed around its own mean, running power sums cancel badly on trending series.
+func rollingMoments(inReal []float64, inTimePeriod int, fn func(today int, mean float64, m2 float64, m3 float64, m4 float64)) {
+
+	if inTimePeriod < 2 {
+		return
+	}
+	n := float64(inTimePeriod)
+	for today := inTimePeriod - 1; today < len(inReal); today++ {
+		sum := 0.0
+		for i := today - inTimePeriod + 1; i <= today; i++ {
+			sum += inReal[i]
+		}
+		mean := sum / n
+		s2, s3, s4 := 0.0, 0.0, 0.0
+		for i := today - inTimePeriod + 1; i <= today; i++ {
+			x := inReal[i] - mean
+			s2 += x * x
+			s3 += x * x * x
+			s4 += x * x * x * x
+		}
+		fn(today, mean, s2/n, s3/n, s4/n)
+	}
+}
+
+// orderStatTree - treap ordered by value with subtree sizes, giving O(log k) insert, remove, rank and k-th value
+type orderStatTree struct {
+	root *orderStatNode
+	seed uint32
+}
+
+type orderStatNode struct {
+	value    float64
+	priority uint32
+	size     int
+	left     *orderStatNode
+	right    *orderStatNode
+}
+
+func (n *orderStatNode) count() int {
+	if n == nil {
+		return 0
+	}
+	return n.size
+}
+
+func (n *orderStatNode) update() {
+	n.size = 1 + n.left.count() + n.right.count()
+}
+
+// split - nodes with value < v (or <= v when inclusive) and the rest
+func (t *orderStatTree) split(n *orderStatNode, v float64, inclusive bool) (*orderStatNode, *orderStatNode) {
+	if n == nil {
+		return nil, nil
+	}
+	if n.value < v || (inclusive && n.value == v) {
+		left, right := t.split(n.right, v, inclusive)
+		n.right = left
+		n.update()
+		return n, right
+	}
+	left, right := t.split(n.left, v, inclusive)
+	n.left = right
+	n.update()
+	return left, n
+}
+
+func (t *orderStatTree) merge(a *orderStatNode, b *orderStatNode) *orderStatNode {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+	if a.priority > b.priority {
+		a.right = t.merge(a.right, b)
+		a.update()
+		return a
+	}
+	b.left = t.merge(a, b.left)
+	b.update()
+	return b
+}
+
+func (t *orderStatTree) insert(v float64) {
+	// xorshift priorities keep the tree balanced and the results deterministic
+	t.seed ^= t.seed << 13
+	t.seed ^= t.seed >> 17
+	t.seed ^= t.seed << 5
+	if t.seed == 0 {
+		t.seed = 2463534242
+	}
+	node := &orderStatNode{value: v, priority: t.seed, size: 1}
+	left, right := t.split(t.root, v, false)
+	t.root = t.merge(t.merge(left, node), right)
+}
+
+func (t *orderStatTree) remove(v float64) {
+	left, right := t.split(t.root, v, false)
+	equal, greater := t.split(right, v, true)
+	if equal != nil {
+		equal = t.merge(equal.left, equal.right)
+	}
+	t.root = t.merge(t.merge(left, equal), greater)
+}
+
+// countLess - number of values strictly below v
+func (t *orderStatTree) countLess(v float64) int {
+	count := 0
+	for n := t.root; n != nil; {
+		if n.value < v {
+			count += 1 + n.left.count()
+			n = n.right
+		} else {
+			n = n.left
+		}
+	}
+	return count
+}
+
+// kth - k-th smallest value, 0 based
+func (t *orderStatTree) kth(k int) float64 {
+	for n := t.root; n != nil; {
+		leftCount := n.left.count()
+		if k < leftCount {
+			n = n.left
+		} else if k == leftCount {
+			return n.value
+		} else {
+			k -= leftCount + 1
+			n = n.right
+		}
+	}
+	return 0.0
+}
+
+// OlsFit - one regression estimate, coefficients ordered intercept first
+type OlsFit struct {
+	Coef     []float64
+	StdErr   []float64
+	TStat    []float64
+	RSquared float64
+	Sigma    float64
+}
+
+// OlsStream - rolling multiple linear regression fed one bar at a time.
+// Means and co-moments are updated with Welford steps as bars enter and leave the window,
+// which stays accurate where raw sums of squares cancel catastrophically.
+type OlsStream struct {
+	period  int
+	window  [][]float64
+	count   int
+	moments *comoments
+}
+
+// NewOlsStream - rolling regression on k regressors over the last timeperiod bars
+// A timeperiod below 1 gives a stream whose window never fills and whose Fit stays empty.
+func NewOlsStream(k int, inTimePeriod int) *OlsStream {
+	if inTimePeriod < 1 {
+		return &OlsStream{period: inTimePeriod, moments: newComoments(k + 1)}
+	}
+	return &OlsStream{period: inTimePeriod, window: make([][]float64, inTimePeriod), moments: newComoments(k + 1)}
+}
+
+// Update adds one bar, the dependent value and one value per regressor, and reports whether the window is full
+func (s *OlsStream) Update(inY float64, inX []float64) bool {
+
+	if s.period < 1 {
+		return false
+	}
+	observation := make([]float64, len(inX)+1)
+	copy(observation, inX)
+	observation[len(inX)] = inY
+	slot := s.count % s.period
+	if trailing := s.window[slot]; trailing != nil {
+		s.moments.remove(trailing)
+	}
+	s.window[slot] = observation
+	s.moments.add(observation)
+	s.count++
+	return s.count >= s.period
+}
+
+// Fit solves the regression over the current window, Coef is nil while the regressors are collinear
+func (s *OlsStream) Fit() OlsFit {
+
+	m := s.moments
+	k := len(m.mean) - 1
+	if m.count == 0 {
+		return OlsFit{}
+	}
+	sxx := newMatrix(k)
+	for i := 0; i < k; i++ {
+		copy(sxx[i], m.c[i][:k])
+	}
+	inverse, ok := invertMatrix(sxx)
+	if !ok {
+		return OlsFit{}
+	}
+
+	fit := OlsFit{Coef: make([]float64, k+1), StdErr: make([]float64, k+1), TStat: make([]float64, k+1)}
+	intercept := m.mean[k]
+	ssr := m.c[k][k]
+	for i := 0; i < k; i++ {
+		slope := 0.0
+		for j := 0; j < k; j++ {
+			slope += inverse[i][j] * m.c[j][k]
+		}
+		fit.Coef[i+1] = slope
+		intercept -= slope * m.mean[i]
+		ssr -= slope * m.c[i][k]
+	}
+	fit.Coef[0] = intercept
+	if ssr < 0.0 {
+		ssr = 0.0
+	}
+	if m.c[k][k] > 0.0 {
+		fit.RSquared = 1.0 - (ssr / m.c[k][k])
+	}
+
+	dof := m.count - k - 1
+	if dof <= 0 {
+		return fit
+	}
+	variance := ssr / float64(dof)
+	fit.Sigma = math.Sqrt(variance)
+	tempReal := 1.0 / float64(m.count)
+	for i := 0; i < k; i++ {
+		for j := 0; j < k; j++ {
+			tempReal += m.mean[i] * inverse[i][j] * m.mean[j]
+		}
+		fit.StdErr[i+1] = math.Sqrt(variance * inverse[i][i])
+	}
+	fit.StdErr[0] = math.Sqrt(variance * tempReal)
+	for j := 0; j <= k; j++ {
+		if fit.StdErr[j] != 0.0 {
+			fit.TStat[j] = fit.Coef[j] / fit.StdErr[j]
+		}
+	}
+	return fit
+}
+
+// comoments - running means and centered cross products of a vector, with Welford add and remove steps
+type comoments struct {
+	count int
+	mean  []float64
+	c     [][]float64
+}
+
+func newComoments(n int) *comoments {
+	return &comoments{mean: make([]float64, n), c: newMatrix(n)}
+}
+
+func (m *comoments) add(x []float64) {
+	m.count++
+	n := len(m.mean)
+	before := make([]float64, n)
+	for i := 0; i < n; i++ {
+		before[i] = x[i] - m.mean[i]
+		m.mean[i] += before[i] / float64(m.count)
+	}
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			m.c[i][j] += before[i] * (x[j] - m.mean[j])
 		}
-		outReal[outIdx] = (inTimePeriodF*sumXY - sumX*sumY) / divisor
-		outIdx++
-		today++
 	}
-	return outReal
 }
 
-// StdDev - Standard Deviation
-func StdDev(inReal []float64, inTimePeriod int, inNbDev float64) []float64 {
-
-	outReal := Var(inReal, inTimePeriod)
+func (m *comoments) remove(x []float64) {
+	n := len(m.mean)
+	if m.count <= 1 {
+		*m = *newComoments(n)
+		return
+	}
+	m.count--
+	after := make([]float64, n)
+	for i := 0; i < n; i++ {
+		after[i] = x[i] - m.mean[i]
+		m.mean[i] -= after[i] / float64(m.count)
+	}
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			m.c[i][j] -= (x[i] - m.mean[i]) * after[j]
+		}
+	}
+}
 
-	if inNbDev != 1.0 {
-		for i := 0; i < len(inReal); i++ {
-			tempReal := outReal[i]
-			if !(tempReal < 0.00000000000001) {
-				outReal[i] = math.Sqrt(tempReal) * inNbDev
-			} else {
-				outReal[i] = 0.0
+// invertMatrix - Gauss-Jordan inverse with partial pivoting, false if the matrix is numerically singular
+func invertMatrix(a [][]float64) ([][]float64, bool) {
+
+	n := len(a)
+	work := newMatrix(n)
+	inverse := newMatrix(n)
+	scale := 0.0
+	for i := 0; i < n; i++ {
+		copy(work[i], a[i])
+		inverse[i][i] = 1.0
+		for j := 0; j < n; j++ {
+			scale = math.Max(scale, math.Abs(a[i][j]))
+		}
+	}
+	for col := 0; col < n; col++ {
+		pivot := col
+		for i := col + 1; i < n; i++ {
+			if math.Abs(work[i][col]) > math.Abs(work[pivot][col]) {
+				pivot = i
 			}
 		}
-	} else {
-		for i := 0; i < len(inReal); i++ {
-			tempReal := outReal[i]
-			if !(tempReal < 0.00000000000001) {
-				outReal[i] = math.Sqrt(tempReal)
-			} else {
-				outReal[i] = 0.0
+		if !(math.Abs(work[pivot][col]) > scale*0.000000000001) {
+			return nil, false
+		}
+		work[col], work[pivot] = work[pivot], work[col]
+		inverse[col], inverse[pivot] = inverse[pivot], inverse[col]
+		tempReal := 1.0 / work[col][col]
+		for j := 0; j < n; j++ {
+			work[col][j] *= tempReal
+			inverse[col][j] *= tempReal
+		}
+		for i := 0; i < n; i++ {
+			if i == col || work[i][col] == 0.0 {
+				continue
+			}
+			factor := work[i][col]
+			for j := 0; j < n; j++ {
+				work[i][j] -= factor * work[col][j]
+				inverse[i][j] -= factor * inverse[col][j]
 			}
 		}
 	}
-	return outReal
+	return inverse, true
 }
 
-// Tsf - Time Series Forecast
-func Tsf(inReal []float64, inTimePeriod int) []float64 {
+// adf - ADF regression and lag selection, leaving p-value and critical values to the caller
+func adf(inReal []float64, inLags int, inRegression AdfRegression) AdfResult {
 
-	outReal := make([]float64, len(inReal))
+	diff := make([]float64, len(inReal))
+	for today := 1; today < len(inReal); today++ {
+		diff[today] = inReal[today] - inReal[today-1]
+	}
+	lags := inLags
+	if lags < 0 {
+		maxLag := int(math.Ceil(12.0 * math.Pow(float64(len(inReal))/100.0, 0.25)))
+		if limit := len(inReal)/2 - int(inRegression) - 1; maxLag > limit {
+			maxLag = limit
+		}
+		if maxLag < 0 {
+			maxLag = 0
+		}
+		// compare every lag count on the sample of the longest one, then refit with the full sample
+		bestAic := math.Inf(1)
+		for lag := 0; lag <= maxLag; lag++ {
+			_, ssr, nobs, ok := adfRegression(inReal, diff, lag, maxLag+1, inRegression)
+			if !ok || !(ssr > 0.0) {
+				continue
+			}
+			aic := (float64(nobs) * math.Log(ssr/float64(nobs))) + (2.0 * float64(lag+int(inRegression)+1))
+			if aic < bestAic {
+				bestAic = aic
+				lags = lag
+			}
+		}
+		if lags < 0 {
+			lags = 0
+		}
+	}
+	stat, _, nobs, _ := adfRegression(inReal, diff, lags, lags+1, inRegression)
+	return AdfResult{Stat: stat, Lags: lags, Nobs: nobs}
+}
 
-	inTimePeriodF := float64(inTimePeriod)
-	lookbackTotal := inTimePeriod
-	startIdx := lookbackTotal
-	outIdx := startIdx - 1
-	today := startIdx - 1
-	sumX := inTimePeriodF * (inTimePeriodF - 1.0) * 0.5
-	sumXSqr := inTimePeriodF * (inTimePeriodF - 1) * (2*inTimePeriodF - 1) / 6
-	divisor := sumX*sumX - inTimePeriodF*sumXSqr
-	//initialize values of sumY and sumXY over first (inTimePeriod) input values
-	sumXY := 0.0
-	sumY := 0.0
-	i := inTimePeriod
-	for i != 0 {
-		i--
-		tempValue1 := inReal[today-i]
-		sumY += tempValue1
-		sumXY += float64(i) * tempValue1
+// adfRegression - fits diff[t] on real[t-1], deterministic terms and lags differences for t >= startIdx;
+// returns the t-statistic of real[t-1], the residual sum of squares and the number of observations
+func adfRegression(inReal []float64, diff []float64, lags int, startIdx int, inRegression AdfRegression) (float64, float64, int, bool) {
+
+	k := 1 + lags + int(inRegression)
+	nobs := len(inReal) - startIdx
+	if nobs <= k {
+		return 0.0, 0.0, nobs, false
+	}
+	xtx := newMatrix(k)
+	xty := make([]float64, k)
+	row := make([]float64, k)
+	for today := startIdx; today < len(inReal); today++ {
+		row[0] = inReal[today-1]
+		for lag := 1; lag <= lags; lag++ {
+			row[lag] = diff[today-lag]
+		}
+		if inRegression >= AdfConstant {
+			row[lags+1] = 1.0
+		}
+		if inRegression == AdfTrend {
+			row[lags+2] = float64(today - startIdx + 1)
+		}
+		for i := 0; i < k; i++ {
+			xty[i] += row[i] * diff[today]
+			for j := 0; j < k; j++ {
+				xtx[i][j] += row[i] * row[j]
+			}
+		}
 	}
-	for today < len(inReal) {
-		//sumX and sumXY are already available for first output value
-		if today > startIdx-1 {
-			tempValue2 := inReal[today-inTimePeriod]
-			sumXY += sumY - inTimePeriodF*tempValue2
-			sumY += inReal[today] - tempValue2
+	inverse, ok := invertMatrix(xtx)
+	if !ok {
+		return 0.0, 0.0, nobs, false
+	}
+	coef := make([]float64, k)
+	for i := 0; i < k; i++ {
+		for j := 0; j < k; j++ {
+			coef[i] += inverse[i][j] * xty[j]
 		}
-		m := (inTimePeriodF*sumXY - sumX*sumY) / divisor
-		b := (sumY - m*sumX) / inTimePeriodF
-		outReal[outIdx] = b + m*inTimePeriodF
-		today++
-		outIdx++
 	}
-	return outReal
+	ssr := 0.0
+	for today := startIdx; today < len(inReal); today++ {
+		tempReal := diff[today] - (coef[0] * inReal[today-1])
+		for lag := 1; lag <= lags; lag++ {
+			tempReal -= coef[lag] * diff[today-lag]
+		}
+		if inRegression >= AdfConstant {
+			tempReal -= coef[lags+1]
+		}
+		if inRegression == AdfTrend {
+			tempReal -= coef[lags+2] * float64(today-startIdx+1)
+		}
+		ssr += tempReal * tempReal
+	}
+	stdErr := math.Sqrt(ssr / float64(nobs-k) * inverse[0][0])
+	if !(stdErr > 0.0) {
+		return 0.0, ssr, nobs, false
+	}
+	return coef[0] / stdErr, ssr, nobs, true
 }
 
-// Var - Variance
-func Var(inReal []float64, inTimePeriod int) []float64 {
+// MacKinnon (2010) response surface coefficients, [variables-1][regression][1%, 5%, 10%]
+// crit = b0 + b1/T + b2/T^2 + b3/T^3
+var mackinnonTau = [2][3][3][4]float64{
+	{
+		{{-2.56574, -2.2358, -3.627, 0.0}, {-1.94100, -0.2686, -3.365, 31.223}, {-1.61682, 0.2656, -2.714, 25.364}},
+		{{-3.43035, -6.5393, -16.786, -79.433}, {-2.86154, -2.8903, -4.234, -40.040}, {-2.56677, -1.5384, -2.809, 0.0}},
+		{{-3.95877, -9.0531, -28.428, -134.155}, {-3.41049, -4.3904, -9.036, -45.374}, {-3.12705, -2.5856, -3.925, -22.380}},
+	},
+	{
+		{},
+		{{-3.89644, -10.9519, -33.527, 0.0}, {-3.33613, -6.1101, -6.823, 0.0}, {-3.04445, -4.2412, -2.720, 0.0}},
+		{},
+	},
+}
 
-	outReal := make([]float64, len(inReal))
+// MacKinnon (1994) p-value approximation, [variables-1][regression]
+// p = N(poly(stat)), with the small p polynomial below tauStar and the large p one above
+var mackinnonTauStar = [2][3]float64{{-1.04, -1.61, -2.89}, {0.0, -2.62, 0.0}}
+var mackinnonTauMin = [2][3]float64{{-19.04, -18.83, -16.18}, {0.0, -18.86, 0.0}}
+var mackinnonTauMax = [2][3]float64{{math.Inf(1), 2.74, 0.7}, {0.0, 0.92, 0.0}}
+var mackinnonSmallP = [2][3][]float64{
+	{{0.6344, 1.2378, 0.032496}, {2.1659, 1.4412, 0.038269}, {3.2512, 1.6047, 0.049588}},
+	{nil, {2.92, 1.5012, 0.039796}, nil},
+}
+var mackinnonLargeP = [2][3][]float64{
+	{{0.4797, 0.93557, -0.06999, 0.033066}, {1.7339, 0.93202, -0.12745, -0.010368}, {2.5261, 0.61654, -0.37956, -0.060285}},
+	{nil, {2.1945, 0.64695, -0.29198, -0.042377}, nil},
+}
 
-	nbInitialElementNeeded := inTimePeriod - 1
-	startIdx := nbInitialElementNeeded
-	periodTotal1 := 0.0
-	periodTotal2 := 0.0
-	trailingIdx := startIdx - nbInitialElementNeeded
-	i := trailingIdx
-	if inTimePeriod > 1 {
-		for i < startIdx {
-			tempReal := inReal[i]
-			periodTotal1 += tempReal
-			tempReal *= tempReal
-			periodTotal2 += tempReal
-			i++
-		}
+// mackinnonCriticalValues - 1%, 5% and 10% critical values for nobs observations
+func mackinnonCriticalValues(nobs int, variables int, inRegression AdfRegression) [3]float64 {
+
+	var outCritical [3]float64
+	if nobs <= 0 {
+		return outCritical
 	}
-	outIdx := startIdx
-	for ok := true; ok; {
-		tempReal := inReal[i]
-		periodTotal1 += tempReal
-		tempReal *= tempReal
-		periodTotal2 += tempReal
-		meanValue1 := periodTotal1 / float64(inTimePeriod)
-		meanValue2 := periodTotal2 / float64(inTimePeriod)
-		tempReal = inReal[trailingIdx]
-		periodTotal1 -= tempReal
-		tempReal *= tempReal
-		periodTotal2 -= tempReal
-		outReal[outIdx] = meanValue2 - meanValue1*meanValue1
-		i++
-		trailingIdx++
-		outIdx++
-		ok = i < len(inReal)
+	tempReal := 1.0 / float64(nobs)
+	for i, b := range mackinnonTau[variables-1][inRegression] {
+		outCritical[i] = b[0] + (tempReal * (b[1] + (tempReal * (b[2] + (tempReal * b[3])))))
 	}
-	return outReal
+	return outCritical
+}
+
+// mackinnonPValue - approximate asymptotic p-value of an ADF statistic
+func mackinnonPValue(stat float64, variables int, inRegression AdfRegression) float64 {
+
+	if stat > mackinnonTauMax[variables-1][inRegression] {
+		return 1.0
+	}
+	if stat < mackinnonTauMin[variables-1][inRegression] {
+		return 0.0
+	}
+	coef := mackinnonLargeP[variables-1][inRegression]
+	if stat <= mackinnonTauStar[variables-1][inRegression] {
+		coef = mackinnonSmallP[variables-1][inRegression]
+	}
+	tempReal := 0.0
+	for i := len(coef) - 1; i >= 0; i-- {
+		tempReal = (tempReal * stat) + coef[i]
+	}
+	return 0.5 * math.Erfc(-tempReal/math.Sqrt2)
 }
 
 /* Math Transform Functions */
@@ -5861,6 +10190,80 @@ func Sum(inReal []float64, inTimePeriod int) []float64 {
 	return outReal
 }
 
+// HeikinAshi - Heikin-Ashi candles
+// haopen, hahigh, halow, haclose = HeikinAshi(open, high, low, close, seedopen=NaN)
+//
+// The Heikin-Ashi open is recursive: the midpoint of the previous Heikin-Ashi open and close.
+// The first open is seeded with inSeedOpen, or with (open+close)/2 of the first bar when inSeedOpen is NaN.
+func HeikinAshi(inOpen []float64, inHigh []float64, inLow []float64, inClose []float64, inSeedOpen float64) ([]float64, []float64, []float64, []float64) {
+
+	outOpen := make([]float64, len(inClose))
+	outHigh := make([]float64, len(inClose))
+	outLow := make([]float64, len(inClose))
+	outClose := make([]float64, len(inClose))
+
+	if len(inClose) == 0 {
+		return outOpen, outHigh, outLow, outClose
+	}
+
+	haOpen := inSeedOpen
+	if math.IsNaN(haOpen) {
+		haOpen = (inOpen[0] + inClose[0]) / 2.0
+	}
+	for today := 0; today < len(inClose); today++ {
+		if today > 0 {
+			haOpen = (outOpen[today-1] + outClose[today-1]) / 2.0
+		}
+		haClose := (inOpen[today] + inHigh[today] + inLow[today] + inClose[today]) / 4.0
+		outOpen[today] = haOpen
+		outClose[today] = haClose
+		outHigh[today] = math.Max(inHigh[today], math.Max(haOpen, haClose))
+		outLow[today] = math.Min(inLow[today], math.Min(haOpen, haClose))
+	}
+
+	return outOpen, outHigh, outLow, outClose
+}
+
+// SmoothedHeikinAshi - Smoothed Heikin-Ashi candles
+// haopen, hahigh, halow, haclose = SmoothedHeikinAshi(open, high, low, close, premaperiod=10, prematype=EMA, postmaperiod=10, postmatype=EMA)
+//
+// The raw OHLC series are smoothed with the pre moving average, Heikin-Ashi candles are built from
+// the smoothed bars and the resulting candles are smoothed again with the post moving average.
+// A period of 1 disables the corresponding smoothing step.
+func SmoothedHeikinAshi(inOpen []float64, inHigh []float64, inLow []float64, inClose []float64, inPreMAPeriod int, inPreMAType MaType, inPostMAPeriod int, inPostMAType MaType) ([]float64, []float64, []float64, []float64) {
+
+	outOpen := make([]float64, len(inClose))
+	outHigh := make([]float64, len(inClose))
+	outLow := make([]float64, len(inClose))
+	outClose := make([]float64, len(inClose))
+
+	preLookback := maLookback(inPreMAPeriod, inPreMAType.Spec())
+	postLookback := maLookback(inPostMAPeriod, inPostMAType.Spec())
+	if preLookback+postLookback >= len(inClose) {
+		return outOpen, outHigh, outLow, outClose
+	}
+
+	smoothOpen := Ma(inOpen, inPreMAPeriod, inPreMAType)[preLookback:]
+	smoothHigh := Ma(inHigh, inPreMAPeriod, inPreMAType)[preLookback:]
+	smoothLow := Ma(inLow, inPreMAPeriod, inPreMAType)[preLookback:]
+	smoothClose := Ma(inClose, inPreMAPeriod, inPreMAType)[preLookback:]
+
+	haOpen, haHigh, haLow, haClose := HeikinAshi(smoothOpen, smoothHigh, smoothLow, smoothClose, math.NaN())
+	haOpen = Ma(haOpen, inPostMAPeriod, inPostMAType)
+	haHigh = Ma(haHigh, inPostMAPeriod, inPostMAType)
+	haLow = Ma(haLow, inPostMAPeriod, inPostMAType)
+	haClose = Ma(haClose, inPostMAPeriod, inPostMAType)
+
+	for i, j := postLookback, preLookback+postLookback; j < len(inClose); i, j = i+1, j+1 {
+		outOpen[j] = haOpen[i]
+		outHigh[j] = haHigh[i]
+		outLow[j] = haLow[i]
+		outClose[j] = haClose[i]
+	}
+
+	return outOpen, outHigh, outLow, outClose
+}
+
 // HeikinashiCandles - from candle values extracts heikinashi candle values.
 //
 // Returns highs, opens, closes and lows of the heikinashi candles (in this order).
@@ -5868,6 +10271,11 @@ func Sum(inReal []float64, inTimePeriod int) []float64 {
 //    NOTE: The number of Heikin-Ashi candles will always be one less than the number of provided candles, due to the fact
 //          that a previous candle is necessary to calculate the Heikin-Ashi candle, therefore the first provided candle is not considered
 //          as "current candle" in the algorithm, but only as "previous candle".
+//
+// The legacy construction is kept unchanged: the high and low ignore the raw low and the open is taken from the previous raw bar.
+//
+// Deprecated: use HeikinAshi, which takes open, high, low, close in the usual order, builds the recursive
+// Heikin-Ashi open and returns one candle per bar.
 func HeikinashiCandles(highs []float64, opens []float64, closes []float64, lows []float64) ([]float64, []float64, []float64, []float64) {
 	N := len(highs)
 
@@ -5911,6 +10319,7 @@ func Hlc3(highs []float64, lows []float64, closes []float64) []float64 {
 //          It assumes first values are the most recent.
 //          The crossover function does not use most recent value, since usually it's not a complete candle.
 //          The second recent values and the previous are used, instead.
+//          See CrossoverSeries for a per-bar signal in chronological order.
 func Crossover(series1 []float64, series2 []float64) bool {
 	if len(series1) < 3 || len(series2) < 3 {
 		return false
@@ -5924,9 +10333,318 @@ func Crossover(series1 []float64, series2 []float64) bool {
 //          It assumes first values are the most recent.
 //          The crossunder function does not use most recent value, since usually it's not a complete candle.
 //          The second recent values and the previous are used, instead.
+//          See CrossunderSeries for a per-bar signal in chronological order.
 func Crossunder(series1 []float64, series2 []float64) bool {
 	if len(series1) < 3 || len(series2) < 3 {
 		return false
 	}
 	return series1[1] >= series2[1] && series1[2] <= series2[2]
 }
+
+// CrossMode - how bars where both series are equal are treated by the crossing functions
+type CrossMode int
+
+// Crossing modes
+const (
+	// CrossLoose signals when the previous bar was below or equal and the current bar is above
+	CrossLoose CrossMode = iota
+	// CrossStrict ignores equal bars and signals when the current bar is above and the last unequal bar was below
+	CrossStrict
+	// CrossTouch signals when the previous bar was below and the current bar is above or equal
+	CrossTouch
+)
+
+// CrossoverSeries - 1.0 on every bar where inReal0 crosses over inReal1, 0.0 otherwise
+// The last element is the most recent bar. When inIncludeLiveBar is false the last bar is considered
+// incomplete and never signals. inLookback is the number of leading bars that are unset in either input
+// (e.g. the larger lookback of the two indicators); the first crossing can happen at bar inLookback+1.
+func CrossoverSeries(inReal0 []float64, inReal1 []float64, inLookback int, inMode CrossMode, inIncludeLiveBar bool) []float64 {
+
+	diff := make([]float64, len(inReal0))
+	for i := 0; i < len(inReal0); i++ {
+		diff[i] = inReal0[i] - inReal1[i]
+	}
+	up, _ := crossSignals(diff, inLookback, inMode, inIncludeLiveBar)
+	return up
+}
+
+// CrossunderSeries - 1.0 on every bar where inReal0 crosses under inReal1, 0.0 otherwise
+// The last element is the most recent bar. When inIncludeLiveBar is false the last bar is considered
+// incomplete and never signals. inLookback is the number of leading bars that are unset in either input
+// (e.g. the larger lookback of the two indicators); the first crossing can happen at bar inLookback+1.
+func CrossunderSeries(inReal0 []float64, inReal1 []float64, inLookback int, inMode CrossMode, inIncludeLiveBar bool) []float64 {
+
+	diff := make([]float64, len(inReal0))
+	for i := 0; i < len(inReal0); i++ {
+		diff[i] = inReal0[i] - inReal1[i]
+	}
+	_, down := crossSignals(diff, inLookback, inMode, inIncludeLiveBar)
+	return down
+}
+
+// Cross - crossings of inReal through a fixed level, e.g. Cross(Rsi(close, 14), 30, 14, CrossLoose, false)
+// 1.0 when crossing up through the level, -1.0 when crossing down, 0.0 otherwise.
+// inLookback is the number of leading unset bars of inReal, which never take part in a crossing.
+func Cross(inReal []float64, inThreshold float64, inLookback int, inMode CrossMode, inIncludeLiveBar bool) []float64 {
+
+	diff := make([]float64, len(inReal))
+	for i := 0; i < len(inReal); i++ {
+		diff[i] = inReal[i] - inThreshold
+	}
+	up, down := crossSignals(diff, inLookback, inMode, inIncludeLiveBar)
+	for i := 0; i < len(up); i++ {
+		up[i] -= down[i]
+	}
+	return up
+}
+
+// crossSignals - up and down crossings of a difference series through zero, ignoring the bars before startIdx
+func crossSignals(inDiff []float64, startIdx int, inMode CrossMode, inIncludeLiveBar bool) ([]float64, []float64) {
+
+	outUp := make([]float64, len(inDiff))
+	outDown := make([]float64, len(inDiff))
+
+	if startIdx < 0 {
+		return outUp, outDown
+	}
+	endIdx := len(inDiff)
+	if !inIncludeLiveBar {
+		endIdx--
+	}
+	lastSign := 0.0
+	for today := startIdx + 1; today < endIdx; today++ {
+		prev := inDiff[today-1]
+		cur := inDiff[today]
+		if prev != 0.0 {
+			lastSign = prev
+		}
+		switch inMode {
+		case CrossLoose:
+			if prev <= 0.0 && cur > 0.0 {
+				outUp[today] = 1.0
+			} else if prev >= 0.0 && cur < 0.0 {
+				outDown[today] = 1.0
+			}
+		case CrossStrict:
+			if lastSign < 0.0 && cur > 0.0 {
+				outUp[today] = 1.0
+			} else if lastSign > 0.0 && cur < 0.0 {
+				outDown[today] = 1.0
+			}
+		case CrossTouch:
+			if prev < 0.0 && cur >= 0.0 {
+				outUp[today] = 1.0
+			} else if prev > 0.0 && cur <= 0.0 {
+				outDown[today] = 1.0
+			}
+		}
+	}
+	return outUp, outDown
+}
+
+/* Parameter Sweeps */
+
+// SweepResult - outputs of an indicator evaluated over a parameter grid.
+// Outputs[i] holds the output series computed for the parameter tuple Params[i].
+type SweepResult struct {
+	Params  [][]float64
+	Outputs [][][]float64
+}
+
+// Get returns the outputs computed for the given parameter tuple, or nil if the tuple is not part of the grid
+func (r SweepResult) Get(params ...float64) [][]float64 {
+	for i, p := range r.Params {
+		if len(p) != len(params) {
+			continue
+		}
+		match := true
+		for j := range p {
+			if p[j] != params[j] {
+				match = false
+				break
+			}
+		}
+		if match {
+			return r.Outputs[i]
+		}
+	}
+	return nil
+}
+
+// Grid - cartesian product of parameter axes, last axis varying fastest
+// grid = Grid([]float64{10, 20}, []float64{1.5, 2}) -> [[10 1.5] [10 2] [20 1.5] [20 2]]
+func Grid(axes ...[]float64) [][]float64 {
+
+	if len(axes) == 0 {
+		return nil
+	}
+	total := 1
+	for _, axis := range axes {
+		total *= len(axis)
+	}
+	outGrid := make([][]float64, total)
+	for i := 0; i < total; i++ {
+		tuple := make([]float64, len(axes))
+		rem := i
+		for j := len(axes) - 1; j >= 0; j-- {
+			tuple[j] = axes[j][rem%len(axes[j])]
+			rem /= len(axes[j])
+		}
+		outGrid[i] = tuple
+	}
+	return outGrid
+}
+
+// Sweep - evaluates fn once per parameter tuple of the grid.
+// This is the naive fallback usable with any indicator, e.g.
+// Sweep(grid, func(p []float64) [][]float64 { return [][]float64{Kama(close, int(p[0]))} })
+func Sweep(inGrid [][]float64, fn func(params []float64) [][]float64) SweepResult {
+
+	result := SweepResult{
+		Params:  make([][]float64, len(inGrid)),
+		Outputs: make([][][]float64, len(inGrid)),
+	}
+	for i, params := range inGrid {
+		result.Params[i] = append([]float64(nil), params...)
+		result.Outputs[i] = fn(params)
+	}
+	return result
+}
+
+// SweepPeriod - evaluates any (inReal, inTimePeriod) indicator for every period, e.g. SweepPeriod(close, periods, Cmo)
+func SweepPeriod(inReal []float64, inTimePeriods []int, fn func([]float64, int) []float64) SweepResult {
+
+	result := SweepResult{
+		Params:  make([][]float64, len(inTimePeriods)),
+		Outputs: make([][][]float64, len(inTimePeriods)),
+	}
+	for i, period := range inTimePeriods {
+		result.Params[i] = []float64{float64(period)}
+		result.Outputs[i] = [][]float64{fn(inReal, period)}
+	}
+	return result
+}
+
+// EmaSweep - Exponential Moving Average for every period of the sweep.
+// The seeding sums are shared between periods, results are identical to Ema.
+func EmaSweep(inReal []float64, inTimePeriods []int) SweepResult {
+
+	result := SweepResult{
+		Params:  make([][]float64, len(inTimePeriods)),
+		Outputs: make([][][]float64, len(inTimePeriods)),
+	}
+
+	maxPeriod := 0
+	for _, period := range inTimePeriods {
+		if period > maxPeriod {
+			maxPeriod = period
+		}
+	}
+	if maxPeriod > len(inReal) {
+		maxPeriod = len(inReal)
+	}
+	runningSum := make([]float64, maxPeriod+1)
+	for i := 0; i < maxPeriod; i++ {
+		runningSum[i+1] = runningSum[i] + inReal[i]
+	}
+
+	for p, period := range inTimePeriods {
+		outReal := make([]float64, len(inReal))
+		result.Params[p] = []float64{float64(period)}
+		result.Outputs[p] = [][]float64{outReal}
+		if period < 1 || period > len(inReal) {
+			continue
+		}
+		k := 2.0 / float64(period+1)
+		prevMA := runningSum[period] / float64(period)
+		outReal[period-1] = prevMA
+		for today := period; today < len(inReal); today++ {
+			prevMA = ((inReal[today] - prevMA) * k) + prevMA
+			outReal[today] = prevMA
+		}
+	}
+	return result
+}
+
+// RsiSweep - Relative strength index for every period of the sweep.
+// Price changes are computed once and shared between periods, results are identical to Rsi.
+func RsiSweep(inReal []float64, inTimePeriods []int) SweepResult {
+
+	result := SweepResult{
+		Params:  make([][]float64, len(inTimePeriods)),
+		Outputs: make([][][]float64, len(inTimePeriods)),
+	}
+
+	gains := make([]float64, len(inReal))
+	losses := make([]float64, len(inReal))
+	for today := 1; today < len(inReal); today++ {
+		tempValue2 := inReal[today] - inReal[today-1]
+		if tempValue2 < 0 {
+			losses[today] = -tempValue2
+		} else {
+			gains[today] = tempValue2
+		}
+	}
+
+	for p, period := range inTimePeriods {
+		outReal := make([]float64, len(inReal))
+		result.Params[p] = []float64{float64(period)}
+		result.Outputs[p] = [][]float64{outReal}
+		if period < 2 || period >= len(inReal) {
+			continue
+		}
+		periodF := float64(period)
+		prevGain := 0.0
+		prevLoss := 0.0
+		for today := 1; today <= period; today++ {
+			prevLoss += losses[today]
+			prevGain += gains[today]
+		}
+		prevLoss /= periodF
+		prevGain /= periodF
+		tempValue1 := prevGain + prevLoss
+		if !((-0.00000000000001 < tempValue1) && (tempValue1 < 0.00000000000001)) {
+			outReal[period] = 100.0 * (prevGain / tempValue1)
+		}
+		for today := period + 1; today < len(inReal); today++ {
+			prevLoss *= periodF - 1
+			prevGain *= periodF - 1
+			prevLoss += losses[today]
+			prevGain += gains[today]
+			prevLoss /= periodF
+			prevGain /= periodF
+			tempValue1 = prevGain + prevLoss
+			if !((-0.00000000000001 < tempValue1) && (tempValue1 < 0.00000000000001)) {
+				outReal[today] = 100.0 * (prevGain / tempValue1)
+			}
+		}
+	}
+	return result
+}
+
+// BBandsSweep - Bollinger Bands over a grid of periods and deviation multipliers.
+// Params are (timeperiod, nbdevup, nbdevdn); the moving average and standard deviation
+// are computed once per period and reused for every deviation pair, results are identical to BBands.
+func BBandsSweep(inReal []float64, inTimePeriods []int, inNbDevUps []float64, inNbDevDns []float64, inMAType MaType) SweepResult {
+
+	result := SweepResult{}
+	for _, period := range inTimePeriods {
+		middleBand := Ma(inReal, period, inMAType)
+		stdDev := StdDev(inReal, period, 1.0)
+		for _, nbDevUp := range inNbDevUps {
+			for _, nbDevDn := range inNbDevDns {
+				outRealUpperBand := make([]float64, len(inReal))
+				outRealMiddleBand := make([]float64, len(inReal))
+				outRealLowerBand := make([]float64, len(inReal))
+				copy(outRealMiddleBand, middleBand)
+				for i := 0; i < len(inReal); i++ {
+					outRealUpperBand[i] = middleBand[i] + (stdDev[i] * nbDevUp)
+					outRealLowerBand[i] = middleBand[i] - (stdDev[i] * nbDevDn)
+				}
+				result.Params = append(result.Params, []float64{float64(period), nbDevUp, nbDevDn})
+				result.Outputs = append(result.Outputs, [][]float64{outRealUpperBand, outRealMiddleBand, outRealLowerBand})
+			}
+		}
+	}
+	return result
+}
@@ -0,0 +1,458 @@
+package talib
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+// The AccBands, AvgDev, Imi and MaVp expected values are hand computations on small inputs chosen so
+// every step is exact arithmetic; they are not output captured from a C TA-Lib 0.6 build, which was not
+// available when these tests were written. Bars inside the lookback are 0, matching the zero filled outputs.
+
+func compareSeries(t *testing.T, name string, got []float64, want []float64) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("%s: got %d values, want %d", name, len(got), len(want))
+	}
+	for i := range want {
+		if math.Abs(got[i]-want[i]) > 1e-9 {
+			t.Errorf("%s[%d] = %v, want %v", name, i, got[i], want[i])
+		}
+	}
+}
+
+func TestAccBands(t *testing.T) {
+	high := []float64{12, 14, 11, 13}
+	low := []float64{8, 6, 9, 7}
+	close := []float64{10, 12, 10, 11}
+
+	// per bar factor 4*(high-low)/(high+low) = 0.8, 1.6, 0.4, 1.2
+	// upper high*(1+factor) = 21.6, 36.4, 15.4, 28.6 and lower low*(1-factor) = 1.6, -3.6, 5.4, -1.4
+	upper, middle, lower := AccBands(high, low, close, 2)
+	compareSeries(t, "upper", upper, []float64{0, 29, 25.9, 22})
+	compareSeries(t, "middle", middle, []float64{0, 11, 11, 10.5})
+	compareSeries(t, "lower", lower, []float64{0, -1, 0.9, 2})
+
+	upper, _, _ = AccBands(high, low, close, 1)
+	compareSeries(t, "period 1", upper, []float64{0, 0, 0, 0})
+}
+
+func TestAvgDev(t *testing.T) {
+	in := []float64{2, 4, 4, 4, 5, 5, 7, 9}
+
+	// window means 3.5, 4.25, 4.5, 5.25, 6.5 with absolute deviation sums 3, 1.5, 2, 3.5, 6
+	compareSeries(t, "avgdev", AvgDev(in, 4), []float64{0, 0, 0, 0.75, 0.375, 0.5, 0.875, 1.5})
+}
+
+func TestImi(t *testing.T) {
+	open := []float64{10, 11, 12, 14, 13, 10}
+	close := []float64{11, 10, 14, 12, 12, 13}
+
+	// close-open = +1, -1, +2, -2, -1, +3
+	compareSeries(t, "imi", Imi(open, close, 3), []float64{0, 0, 75, 40, 40, 50})
+}
+
+func TestMaVp(t *testing.T) {
+	in := []float64{1, 2, 3, 4, 5, 6, 7, 8}
+	periods := []float64{2, 2, 3, 3, 4, 4, 2, 3}
+
+	// the SMA lookback at maxperiod=4 is 3, each later bar averages its own clamped period
+	compareSeries(t, "sma", MaVp(in, periods, 2, 4, SMA), []float64{0, 0, 0, 3, 3.5, 4.5, 6.5, 7})
+
+	// the DEMA lookback at maxperiod=4 is 2*(4-1) = 6 rather than maxperiod-1
+	dema := Dema(in, 2)
+	got := MaVp(in, []float64{2, 2, 2, 2, 2, 2, 2, 2}, 2, 4, DEMA)
+	compareSeries(t, "dema", got, []float64{0, 0, 0, 0, 0, 0, dema[6], dema[7]})
+}
+
+func TestMaWithSpecVwmaRequiresVolume(t *testing.T) {
+	in := []float64{1, 2, 3, 4, 5, 6}
+	volume := []float64{1, 1, 2, 2, 1, 1}
+
+	compareSeries(t, "no volume", MaWithSpec(in, 3, MaSpec{Type: VWMA}), []float64{0, 0, 0, 0, 0, 0})
+	compareSeries(t, "short volume", MaWithSpec(in, 3, VwmaSpec(volume[:4])), []float64{0, 0, 0, 0, 0, 0})
+	// (1*1 + 2*1 + 3*2)/4, (2*1 + 3*2 + 4*2)/5, (3*2 + 4*2 + 5*1)/5, (4*2 + 5*1 + 6*1)/4
+	compareSeries(t, "volume", MaWithSpec(in, 3, VwmaSpec(volume)), []float64{0, 0, 2.25, 3.2, 3.8, 4.75})
+}
+
+func TestBareVwmaIsZero(t *testing.T) {
+	in := []float64{1, 2, 3, 4, 5, 6}
+
+	compareSeries(t, "ma", Ma(in, 3, VWMA), []float64{0, 0, 0, 0, 0, 0})
+	_, middle, _ := BBandsWithSpec(in, 3, 2, 2, VwmaSpec([]float64{1, 1, 1, 1, 1, 1}))
+	compareSeries(t, "bbands", middle, []float64{0, 0, 2, 3, 4, 5})
+}
+
+func TestDonchian(t *testing.T) {
+	high := []float64{5, 7, 6, 8, 4}
+	low := []float64{1, 3, 2, 4, 2}
+
+	upper, middle, lower := Donchian(high, low, 3)
+	compareSeries(t, "upper", upper, []float64{0, 0, 7, 8, 8})
+	compareSeries(t, "middle", middle, []float64{0, 0, 4, 5, 5})
+	compareSeries(t, "lower", lower, []float64{0, 0, 1, 2, 2})
+
+	for _, period := range []int{0, -1} {
+		upper, middle, lower = Donchian(high, low, period)
+		compareSeries(t, "upper", upper, []float64{0, 0, 0, 0, 0})
+		compareSeries(t, "middle", middle, []float64{0, 0, 0, 0, 0})
+		compareSeries(t, "lower", lower, []float64{0, 0, 0, 0, 0})
+	}
+}
+
+func TestStopStreamsInvalidPeriod(t *testing.T) {
+	high := []float64{10, 11, 12, 11, 13, 14}
+	low := []float64{9, 10, 10, 9, 11, 12}
+	close := []float64{9.5, 10.5, 11.5, 10, 12.5, 13}
+	zeros := []float64{0, 0, 0, 0, 0, 0}
+
+	for _, period := range []int{0, -1} {
+		longStop, shortStop, direction := ChandelierExit(high, low, close, period, 3)
+		compareSeries(t, "chandelier long", longStop, zeros)
+		compareSeries(t, "chandelier short", shortStop, zeros)
+		compareSeries(t, "chandelier direction", direction, zeros)
+		stop, direction := KaseDevStop(high, low, close, period, 2.2)
+		compareSeries(t, "kase stop", stop, zeros)
+		compareSeries(t, "kase direction", direction, zeros)
+	}
+}
+
+func TestVolumeProfile(t *testing.T) {
+	high := []float64{3, 2, 5}
+	low := []float64{1, 2, 4}
+	close := []float64{2, 2, 4}
+	volume := []float64{30, 20, 10}
+
+	// uniform spread: levels 1, 2, 3 get 10 each from the first bar, level 2 gets 20 more from the second
+	// and levels 4, 5 get 5 each from the third. The value area (70% of 60 = 42) grows from the POC at 2
+	// to 3 (ties go up, 40) and then to 1 (10 beats 5, 50).
+	profile := VolumeProfile(high, low, close, volume, 1, 0.7, ProfileUniform)
+	compareSeries(t, "prices", profile.Prices, []float64{1, 2, 3, 4, 5})
+	compareSeries(t, "values", profile.Values, []float64{10, 30, 10, 5, 5})
+	if profile.Poc != 2 || profile.Vah != 3 || profile.Val != 1 {
+		t.Errorf("poc, vah, val = %v, %v, %v, want 2, 3, 1", profile.Poc, profile.Vah, profile.Val)
+	}
+
+	poc, vah, val := RollingVolumeProfile(high, low, close, volume, 2, 1, 0.7, ProfileUniform)
+	// bars 0-1: levels 1, 2, 3 hold 10, 30, 10 and the value area (35) is reached at 2-3;
+	// bars 1-2: levels 2, 4, 5 hold 20, 5, 5 and the value area (21) is reached at 2-4
+	compareSeries(t, "rolling poc", poc, []float64{0, 2, 2})
+	compareSeries(t, "rolling vah", vah, []float64{0, 3, 4})
+	compareSeries(t, "rolling val", val, []float64{0, 2, 2})
+
+	for _, binSize := range []float64{0, -1, math.NaN()} {
+		profile = VolumeProfile(high, low, close, volume, binSize, 0.7, ProfileUniform)
+		if len(profile.Prices) != 0 || profile.Poc != 0 || profile.Vah != 0 || profile.Val != 0 {
+			t.Errorf("binsize %v: got %+v, want an empty profile", binSize, profile)
+		}
+		poc, _, _ = RollingVolumeProfile(high, low, close, volume, 2, binSize, 0.7, ProfileUniform)
+		compareSeries(t, "invalid binsize poc", poc, []float64{0, 0, 0})
+		if profiles := TpoProfiles(high, low, make([]time.Time, 3), nil, 0, binSize, 0.7); profiles != nil {
+			t.Errorf("binsize %v: got %d TPO profiles, want none", binSize, len(profiles))
+		}
+	}
+}
+
+func TestEwmaVar(t *testing.T) {
+	returns := []float64{0.1, -0.2, 0.3}
+
+	// seeded with 0.1^2, then 0.9*v + 0.1*r^2 of the previous return
+	variance, forecast := EwmaVar(returns, 0.9, 2)
+	compareSeries(t, "variance", variance, []float64{0.01, 0.01, 0.013})
+	compareSeries(t, "forecast", forecast, []float64{0.0207, 0.0207})
+}
+
+func TestGarchFitRecoversSimulatedModel(t *testing.T) {
+	want := GarchModel{Omega: 0.00001, Alpha: 0.1, Beta: 0.85}
+	random := rand.New(rand.NewSource(7))
+	returns := make([]float64, 10000)
+	variance := want.Omega / (1.0 - want.Alpha - want.Beta)
+	for i := range returns {
+		returns[i] = math.Sqrt(variance) * random.NormFloat64()
+		variance = want.Omega + (want.Alpha * returns[i] * returns[i]) + (want.Beta * variance)
+	}
+
+	got := GarchFit(returns)
+	if math.Abs(got.Alpha-want.Alpha) > 0.03 || math.Abs(got.Beta-want.Beta) > 0.05 || math.Abs(got.Omega-want.Omega)/want.Omega > 0.5 {
+		t.Errorf("fit = %+v, want close to %+v", got, want)
+	}
+	if !(got.Alpha >= 0.0 && got.Beta >= 0.0 && got.Alpha+got.Beta < 1.0) {
+		t.Errorf("fit %+v is not stationary", got)
+	}
+
+	// forecasts revert monotonically towards the unconditional variance omega/(1-alpha-beta)
+	longRun := got.Omega / (1.0 - got.Alpha - got.Beta)
+	forecast := got.Forecast(returns, 500)
+	for i := 1; i < len(forecast); i++ {
+		if math.Abs(forecast[i]-longRun) > math.Abs(forecast[i-1]-longRun) {
+			t.Fatalf("forecast[%d] = %v moves away from the long run variance %v", i, forecast[i], longRun)
+		}
+	}
+	if math.Abs(forecast[len(forecast)-1]-longRun)/longRun > 1e-6 {
+		t.Errorf("forecast after 500 steps = %v, want %v", forecast[len(forecast)-1], longRun)
+	}
+}
+
+func TestCovMatrixLargeOffset(t *testing.T) {
+	x := make([]float64, 40)
+	y := make([]float64, 40)
+	for i := range x {
+		x[i] = 1e9 + float64(i%5)
+		y[i] = 1e9 - 2.0*float64(i%3) + 0.5*float64(i%5)
+	}
+	inReals := [][]float64{x, y}
+
+	// two-pass population covariance of the window ending at today
+	twoPass := func(a []float64, b []float64, today int, period int) float64 {
+		meanA, meanB := 0.0, 0.0
+		for i := today - period + 1; i <= today; i++ {
+			meanA += a[i] - 1e9
+			meanB += b[i] - 1e9
+		}
+		meanA /= float64(period)
+		meanB /= float64(period)
+		cov := 0.0
+		for i := today - period + 1; i <= today; i++ {
+			cov += (a[i] - 1e9 - meanA) * (b[i] - 1e9 - meanB)
+		}
+		return cov / float64(period)
+	}
+
+	cov := CovMatrix(inReals, 20)
+	for today := 19; today < len(x); today++ {
+		for i, a := range inReals {
+			for j, b := range inReals {
+				if want := twoPass(a, b, today, 20); math.Abs(cov[today][i][j]-want) > 1e-6 {
+					t.Errorf("cov[%d][%d][%d] = %v, want %v", today, i, j, cov[today][i][j], want)
+				}
+			}
+		}
+	}
+	if math.Abs(cov[39][0][0]-2.0) > 1e-6 {
+		t.Errorf("variance = %v, want 2", cov[39][0][0])
+	}
+	correl := CorrelMatrix(inReals, 20)
+	if math.Abs(correl[39][0][0]-1.0) > 1e-9 || math.Abs(correl[39][1][1]-1.0) > 1e-9 {
+		t.Errorf("correl diagonal = %v, %v, want 1", correl[39][0][0], correl[39][1][1])
+	}
+}
+
+func TestRollingOls(t *testing.T) {
+	// one regressor over five bars: xbar=3, ybar=4, Sxx=10, Sxy=6, Syy=6,
+	// slope 0.6, intercept 2.2, residuals -0.8, 0.6, 1, -0.6, -0.2, SSR=2.4 and sigma^2 = 2.4/3 = 0.8
+	x := []float64{1, 2, 3, 4, 5}
+	y := []float64{2, 4, 5, 4, 5}
+	coef, stderr, tstat, rsquared, residual := RollingOls(y, [][]float64{x}, 5)
+	compareSeries(t, "intercept", coef[0], []float64{0, 0, 0, 0, 2.2})
+	compareSeries(t, "slope", coef[1], []float64{0, 0, 0, 0, 0.6})
+	// se(slope) = sqrt(0.8/10), se(intercept) = sqrt(0.8*(1/5 + 3^2/10))
+	compareSeries(t, "stderr intercept", stderr[0], []float64{0, 0, 0, 0, math.Sqrt(0.88)})
+	compareSeries(t, "stderr slope", stderr[1], []float64{0, 0, 0, 0, math.Sqrt(0.08)})
+	compareSeries(t, "tstat intercept", tstat[0], []float64{0, 0, 0, 0, 2.2 / math.Sqrt(0.88)})
+	compareSeries(t, "tstat slope", tstat[1], []float64{0, 0, 0, 0, 0.6 / math.Sqrt(0.08)})
+	compareSeries(t, "rsquared", rsquared, []float64{0, 0, 0, 0, 0.6})
+	compareSeries(t, "residual", residual, []float64{0, 0, 0, 0, -0.2})
+
+	// the same five bars as the trailing window after an extra leading bar
+	coef, stderr, _, _, residual = RollingOls(append([]float64{0}, y...), [][]float64{append([]float64{9}, x...)}, 5)
+	compareSeries(t, "rolling", []float64{coef[0][5], coef[1][5], stderr[1][5], residual[5]}, []float64{2.2, 0.6, math.Sqrt(0.08), -0.2})
+
+	// two centered orthogonal regressors: Sxx = diag(4, 4), Sxy = (6, 4), ybar = 3,
+	// coefficients 3, 1.5, 1, residuals +-0.5 on the first four bars, SSR=1, sigma^2 = 1/3 and SST=14
+	x1 := []float64{-1, 1, -1, 1, 0, 0}
+	x2 := []float64{-1, -1, 1, 1, 0, 0}
+	y = []float64{1, 3, 2, 6, 3, 3}
+	stream := NewOlsStream(2, 6)
+	for i := range y {
+		stream.Update(y[i], []float64{x1[i], x2[i]})
+	}
+	fit := stream.Fit()
+	compareSeries(t, "coef", fit.Coef, []float64{3, 1.5, 1})
+	compareSeries(t, "stderr", fit.StdErr, []float64{math.Sqrt(1.0 / 18.0), math.Sqrt(1.0 / 12.0), math.Sqrt(1.0 / 12.0)})
+	compareSeries(t, "tstat", fit.TStat, []float64{3 / math.Sqrt(1.0/18.0), 1.5 / math.Sqrt(1.0/12.0), 1 / math.Sqrt(1.0/12.0)})
+	compareSeries(t, "fit", []float64{fit.RSquared, fit.Sigma}, []float64{13.0 / 14.0, math.Sqrt(1.0 / 3.0)})
+
+	// collinear regressors leave every output at 0
+	coef, stderr, tstat, rsquared, residual = RollingOls(y, [][]float64{x1, x1}, 4)
+	for _, series := range [][]float64{coef[0], coef[1], coef[2], stderr[1], tstat[1], rsquared, residual} {
+		compareSeries(t, "collinear", series, []float64{0, 0, 0, 0, 0, 0})
+	}
+
+	for _, period := range []int{0, -1} {
+		coef, _, _, rsquared, residual = RollingOls(y, [][]float64{x1}, period)
+		for _, series := range [][]float64{coef[0], coef[1], rsquared, residual} {
+			compareSeries(t, "invalid period", series, []float64{0, 0, 0, 0, 0, 0})
+		}
+		if stream = NewOlsStream(1, period); stream.Update(1, []float64{1}) || stream.Fit().Coef != nil {
+			t.Errorf("period %d: stream should never fill", period)
+		}
+	}
+}
+
+func TestAdf(t *testing.T) {
+	// with no lagged differences the ADF statistic is the plain Dickey-Fuller t-statistic of b in
+	// diff[t] = a + b*real[t-1], computed here in closed form
+	in := []float64{1, 3, 2, 4, 3, 5, 2, 4, 6, 5, 3, 4}
+	m := float64(len(in) - 1)
+	meanX, meanY := 0.0, 0.0
+	for i := 1; i < len(in); i++ {
+		meanX += in[i-1] / m
+		meanY += (in[i] - in[i-1]) / m
+	}
+	sxx, sxy := 0.0, 0.0
+	for i := 1; i < len(in); i++ {
+		sxx += (in[i-1] - meanX) * (in[i-1] - meanX)
+		sxy += (in[i-1] - meanX) * (in[i] - in[i-1] - meanY)
+	}
+	slope := sxy / sxx
+	ssr := 0.0
+	for i := 1; i < len(in); i++ {
+		tempReal := in[i] - in[i-1] - meanY - slope*(in[i-1]-meanX)
+		ssr += tempReal * tempReal
+	}
+	result := Adf(in, 0, AdfConstant)
+	if want := slope / math.Sqrt(ssr/(m-2.0)/sxx); math.Abs(result.Stat-want) > 1e-9 || result.Lags != 0 || result.Nobs != len(in)-1 {
+		t.Errorf("Adf = %+v, want stat %v with 0 lags over %d observations", result, want, len(in)-1)
+	}
+
+	// the MacKinnon (1994) p-values and (2010) critical values agree asymptotically:
+	// the asymptotic 1%, 5% and 10% critical values map to those p-values
+	for _, c := range []struct {
+		variables  int
+		regression AdfRegression
+	}{{1, AdfNone}, {1, AdfConstant}, {1, AdfTrend}, {2, AdfConstant}} {
+		critical := mackinnonCriticalValues(1<<50, c.variables, c.regression)
+		for i, want := range []float64{0.01, 0.05, 0.10} {
+			if p := mackinnonPValue(critical[i], c.variables, c.regression); math.Abs(p-want) > 0.001 {
+				t.Errorf("variables %d regression %d: p-value at %v = %v, want %v", c.variables, c.regression, critical[i], p, want)
+			}
+		}
+	}
+	// asymptotic constant-only critical values as published in MacKinnon (2010), table 2
+	critical := mackinnonCriticalValues(1<<50, 1, AdfConstant)
+	compareSeries(t, "asymptotic critical values", critical[:], []float64{-3.43035, -2.86154, -2.56677})
+
+	random := rand.New(rand.NewSource(3))
+	walk := make([]float64, 500)
+	ar := make([]float64, 500)
+	for i := 1; i < len(walk); i++ {
+		walk[i] = walk[i-1] + random.NormFloat64()
+		ar[i] = 0.5*ar[i-1] + random.NormFloat64()
+	}
+	if result = Adf(walk, -1, AdfConstant); !(result.PValue > 0.1) || result.Stat < result.CriticalValues[2] {
+		t.Errorf("random walk: %+v, want the unit root not rejected", result)
+	}
+	if result = Adf(ar, -1, AdfConstant); !(result.PValue < 0.01) || result.Stat > result.CriticalValues[0] {
+		t.Errorf("AR(1) with phi 0.5: %+v, want the unit root rejected", result)
+	}
+
+	// y = 1 + 2x + stationary noise is cointegrated with x, an independent walk is not
+	y := make([]float64, len(walk))
+	other := make([]float64, len(walk))
+	for i := range walk {
+		y[i] = 1.0 + 2.0*walk[i] + ar[i]
+		if i > 0 {
+			other[i] = other[i-1] + random.NormFloat64()
+		}
+	}
+	result, hedgeRatio, _ := EngleGranger(y, walk, -1)
+	if !(result.PValue < 0.01) || math.Abs(hedgeRatio-2.0) > 0.05 {
+		t.Errorf("cointegrated pair: %+v with hedge ratio %v, want rejection and a ratio near 2", result, hedgeRatio)
+	}
+	if result, _, _ = EngleGranger(other, walk, -1); !(result.PValue > 0.1) {
+		t.Errorf("independent walks: %+v, want no cointegration", result)
+	}
+}
+
+func TestHedgeRatioAndHalfLife(t *testing.T) {
+	x := []float64{1, 4, 2, 8, 5, 7}
+	y := make([]float64, len(x))
+	for i := range x {
+		y[i] = 1.0 + 2.0*x[i]
+	}
+	for _, method := range []HedgeMethod{HedgeOls, HedgeTls} {
+		ratio, intercept := HedgeRatio(y, x, 3, method)
+		compareSeries(t, "ratio", ratio, []float64{0, 0, 2, 2, 2, 2})
+		compareSeries(t, "intercept", intercept, []float64{0, 0, 1, 1, 1, 1})
+	}
+
+	// diff[t] = -0.5*real[t-1] exactly: b = -0.5 and the half-life is -ln(2)/ln(0.5) = 1 bar
+	spread := []float64{16, 8, 4, 2, 1, 0.5}
+	compareSeries(t, "halflife", HalfLife(spread, 3), []float64{0, 0, 0, 1, 1, 1})
+	// a trending spread does not revert
+	if halfLife := HalfLife([]float64{1, 2, 4, 8, 16}, 3); !math.IsInf(halfLife[4], 1) {
+		t.Errorf("halflife of a diverging spread = %v, want +Inf", halfLife[4])
+	}
+}
+
+func TestEhlersInvalidPeriods(t *testing.T) {
+	high := make([]float64, 40)
+	low := make([]float64, 40)
+	zeros := make([]float64, 40)
+	for i := range high {
+		high[i] = 10.0 + math.Sin(float64(i)/3.0)
+		low[i] = high[i] - 1.0
+	}
+
+	for _, period := range []int{0, -1} {
+		fisher, trigger := Fisher(high, low, period)
+		compareSeries(t, "fisher", fisher, zeros)
+		compareSeries(t, "trigger", trigger, zeros)
+		compareSeries(t, "supersmoother", SuperSmoother(high, period), zeros)
+		compareSeries(t, "decycler", Decycler(high, period), zeros)
+		compareSeries(t, "roofing hp", RoofingFilter(high, period, 10), zeros)
+		compareSeries(t, "roofing ss", RoofingFilter(high, 48, period), zeros)
+		compareSeries(t, "sinewave hp", EvenBetterSinewave(high, period, 10), zeros)
+		compareSeries(t, "sinewave ss", EvenBetterSinewave(high, 40, period), zeros)
+		compareSeries(t, "periodogram", AutocorrelationPeriodogram(high, period, 48, 3), zeros)
+	}
+	compareSeries(t, "periodogram max below min", AutocorrelationPeriodogram(high, 10, 8, 3), zeros)
+}
+
+func TestFilterDesigns(t *testing.T) {
+	closeTo := func(name string, got float64, want float64, tolerance float64) {
+		t.Helper()
+		if math.Abs(got-want) > tolerance {
+			t.Errorf("%s = %v, want %v", name, got, want)
+		}
+	}
+	gain := func(f Filter, period float64) float64 {
+		g, _ := f.FrequencyResponse(period)
+		return g
+	}
+
+	for _, poles := range []int{1, 2, 3, 4} {
+		lowPass := ButterworthLowPass(20, poles)
+		closeTo("butterworth low pass dc gain", gain(lowPass, math.Inf(1)), 1.0, 1e-9)
+		closeTo("butterworth low pass cutoff gain", gain(lowPass, 20), math.Sqrt(0.5), 1e-9)
+		closeTo("butterworth low pass nyquist gain", gain(lowPass, 2), 0.0, 1e-9)
+
+		highPass := ButterworthHighPass(20, poles)
+		closeTo("butterworth high pass dc gain", gain(highPass, math.Inf(1)), 0.0, 1e-9)
+		closeTo("butterworth high pass cutoff gain", gain(highPass, 20), math.Sqrt(0.5), 1e-9)
+		closeTo("butterworth high pass nyquist gain", gain(highPass, 2), 1.0, 1e-9)
+
+		gaussian := GaussianLowPass(20, poles)
+		closeTo("gaussian low pass dc gain", gain(gaussian, math.Inf(1)), 1.0, 1e-9)
+		closeTo("gaussian low pass cutoff gain", gain(gaussian, 20), math.Sqrt(0.5), 1e-9)
+
+		gaussian = GaussianHighPass(20, poles)
+		closeTo("gaussian high pass dc gain", gain(gaussian, math.Inf(1)), 0.0, 1e-9)
+		closeTo("gaussian high pass nyquist gain", gain(gaussian, 2), 1.0, 1e-9)
+	}
+
+	// linear phase: the SMA delays every cycle by (n-1)/2 bars, and the EMA lags the trend as much
+	for _, n := range []int{2, 5, 10} {
+		sma := SmaFilter(n)
+		closeTo("sma lag", sma.Lag(), float64(n-1)/2.0, 1e-9)
+		closeTo("sma group delay", sma.GroupDelay(7), float64(n-1)/2.0, 1e-9)
+		closeTo("ema lag", EmaFilter(n).Lag(), float64(n-1)/2.0, 1e-9)
+	}
+
+	in := []float64{1, 4, 2, 8, 5, 7, 3, 6}
+	want := Sma(in, 3)
+	got := SmaFilter(3).Apply(in)
+	compareSeries(t, "sma filter", got[2:], want[2:])
+}